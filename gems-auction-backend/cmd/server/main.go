@@ -7,9 +7,11 @@ import (
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/handler"
+	"github.com/boswin/gems-auction-backend/internal/mailer"
 	"github.com/boswin/gems-auction-backend/internal/middleware"
 	"github.com/boswin/gems-auction-backend/internal/repository"
 	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/boswin/gems-auction-backend/internal/storage"
 	"github.com/boswin/gems-auction-backend/internal/websocket"
 
 	"github.com/gin-contrib/cors"
@@ -32,43 +34,128 @@ func main() {
 	// ===============================
 	// 3️⃣ Initialize WebSocket Manager
 	// ===============================
-	wsManager := websocket.NewManager()
+	wsManager := websocket.NewManager(config.AppConfig.WSAllowedOrigins)
+
+	// replicas share bid and chat events so every viewer sees them
+	switch config.AppConfig.WSPubSub {
+	case "postgres":
+		wsManager.UsePubSub(websocket.NewPostgresPubSub(config.DB))
+	case "":
+	default:
+		log.Fatal("Invalid WS_PUBSUB value")
+	}
+
+	// ===============================
+	// 📦 Blob Storage (gem images)
+	// ===============================
+	blobStore, err := storage.NewLocalStore(config.AppConfig.UploadDir, config.AppConfig.MediaURLPrefix)
+	if err != nil {
+		log.Fatal("Unable to initialize upload storage:", err)
+	}
+
+	// private documents (seller applications) are never served statically
+	privateStore, err := storage.NewLocalStore(config.AppConfig.PrivateUploadDir, "")
+	if err != nil {
+		log.Fatal("Unable to initialize private storage:", err)
+	}
+
+	// ===============================
+	// 📧 Mailer
+	// ===============================
+	var mail mailer.Mailer
+	switch config.AppConfig.MailDriver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(
+			config.AppConfig.SMTPHost,
+			config.AppConfig.SMTPPort,
+			config.AppConfig.SMTPUsername,
+			config.AppConfig.SMTPPassword,
+			config.AppConfig.MailFrom,
+		)
+	case "file":
+		mail, err = mailer.NewFileMailer(config.AppConfig.MailDir, config.AppConfig.MailFrom)
+		if err != nil {
+			log.Fatal("Unable to initialize mail directory:", err)
+		}
+	default:
+		mail = mailer.NewLogMailer(config.AppConfig.MailFrom)
+	}
 
 	// ===============================
 	// 4️⃣ Initialize Repositories
 	// ===============================
 	userRepo := repository.NewUserRepository()
+	roleRepo := repository.NewRoleRepository()
+	refreshRepo := repository.NewRefreshTokenRepository()
+	userTokenRepo := repository.NewUserTokenRepository()
+	sellerAppRepo := repository.NewSellerApplicationRepository()
 	gemRepo := repository.NewGemRepository()
+	gemImageRepo := repository.NewGemImageRepository()
+	vocabRepo := repository.NewVocabularyRepository()
+	certRepo := repository.NewCertificateRepository()
+	provRepo := repository.NewProvenanceRepository()
 	auctionRepo := repository.NewAuctionRepository()
 	bidRepo := repository.NewBidRepository()
 	chatRepo := repository.NewChatRepository()
+	twoFactorRepo := repository.NewTwoFactorRepository()
+	loginAttemptRepo := repository.NewLoginAttemptRepository()
+	addressRepo := repository.NewAddressRepository()
+	kycRepo := repository.NewKYCRepository()
+	reviewRepo := repository.NewReviewRepository()
 
 	// ===============================
 	// 5️⃣ Initialize Services
 	// ===============================
-	authService := service.NewAuthService(userRepo)
-	gemService := service.NewGemService(gemRepo)
-	auctionService := service.NewAuctionService(auctionRepo)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, roleRepo, userRepo)
+	authService := service.NewAuthService(userRepo, roleRepo, refreshRepo, userTokenRepo, loginAttemptRepo, mail, twoFactorService)
+	roleService := service.NewRoleService(userRepo, roleRepo)
+	profileService := service.NewProfileService(userRepo, addressRepo, reviewRepo)
+	kycService := service.NewKYCService(kycRepo, privateStore, config.AppConfig.MaxUploadBytes)
+	adminUserService := service.NewAdminUserService(userRepo, roleRepo, authService, wsManager)
+	sellerAppService := service.NewSellerApplicationService(sellerAppRepo, roleRepo, privateStore, config.AppConfig.MaxUploadBytes)
+	vocabService := service.NewVocabularyService(vocabRepo)
+	gemService := service.NewGemService(gemRepo, vocabService)
+	gemImageService := service.NewGemImageService(gemRepo, gemImageRepo, blobStore, config.AppConfig.MaxUploadBytes)
+	certService := service.NewCertificateService(gemRepo, certRepo, blobStore, config.AppConfig.MaxUploadBytes)
+	provService := service.NewProvenanceService(gemRepo, provRepo)
+	auctionService := service.NewAuctionService(auctionRepo, bidRepo, chatRepo, wsManager, wsManager)
 	bidService := service.NewBidService(bidRepo, auctionRepo, wsManager)
 	chatService := service.NewChatService(chatRepo, wsManager)
+	reviewService := service.NewReviewService(reviewRepo, userRepo)
 	paymentService := service.NewPaymentService()
 
-	_ = paymentService
-
 	// ===============================
 	// 6️⃣ Initialize Handlers
 	// ===============================
 	authHandler := handler.NewAuthHandler(authService)
-	gemHandler := handler.NewGemHandler(gemService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	profileHandler := handler.NewProfileHandler(profileService)
+	kycHandler := handler.NewKYCHandler(kycService)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService)
+	sellerAppHandler := handler.NewSellerApplicationHandler(sellerAppService)
+	gemHandler := handler.NewGemHandler(gemService, gemImageService)
 	auctionHandler := handler.NewAuctionHandler(auctionService)
 	bidHandler := handler.NewBidHandler(bidService)
 	chatHandler := handler.NewChatHandler(chatService)
-	wsHandler := handler.NewWebSocketHandler(wsManager)
+	wsHandler := handler.NewWebSocketHandler(wsManager, auctionService, bidService, chatService)
+	vocabHandler := handler.NewVocabularyHandler(vocabService)
+	certHandler := handler.NewCertificateHandler(certService)
+	provHandler := handler.NewProvenanceHandler(provService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
 
 	// ===============================
 	// 7️⃣ Setup Gin Router
 	// ===============================
 	r := gin.New()
+	r.MaxMultipartMemory = config.AppConfig.MaxUploadBytes
+
+	// login throttling and session history rely on ClientIP, so forwarded
+	// headers are only believed from known proxies
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES:", err)
+	}
 
 	// Logging + Recovery
 	r.Use(middleware.LoggingMiddleware())
@@ -97,10 +184,17 @@ func main() {
 		})
 	})
 
+	// ===============================
+	// 🖼️ Uploaded Media (local blob store)
+	// ===============================
+	r.Static(config.AppConfig.MediaURLPrefix, config.AppConfig.UploadDir)
+
 	// ===============================
 	// 9️⃣ WebSocket Route
 	// ===============================
-	wsHandler.RegisterRoutes(r)
+	// the handshake carries the access token itself; anonymous spectators
+	// are let in where the auction allows them
+	wsHandler.RegisterRoutes(r.Group("", middleware.WebSocketAuthMiddleware(authService)))
 
 	// ===============================
 	// 🔟 API ROUTES
@@ -113,7 +207,9 @@ func main() {
 
 	// -------- Protected Routes --------
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(authService))
+
+	protected.POST("/auth/logout-all", authHandler.LogoutAll)
 
 	// =====================================
 	// GEMS ROUTES
@@ -121,42 +217,138 @@ func main() {
 	gems := protected.Group("/gems")
 
 	gems.POST("",
-		middleware.RoleMiddleware("SELLER", "ADMIN"),
+		middleware.PermissionMiddleware("gem:create"),
 		gemHandler.CreateGem,
 	)
 
+	gems.GET("/search", gemHandler.SearchGems)
 	gems.GET("/:id", gemHandler.GetGemByID)
+	gems.GET("/:id/edits", gemHandler.GetGemEdits)
+
+	gems.PATCH("/:id",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.UpdateGem,
+	)
+
+	gems.POST("/:id/withdraw",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.WithdrawGem,
+	)
+
+	gems.GET("/:id/images", gemHandler.ListGemImages)
+
+	gems.POST("/:id/images",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.UploadGemImages,
+	)
+
+	gems.PUT("/:id/images/order",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.ReorderGemImages,
+	)
+
+	gems.POST("/:id/images/:imageId/primary",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.SetPrimaryGemImage,
+	)
+
+	gems.DELETE("/:id/images/:imageId",
+		middleware.PermissionMiddleware("gem:create"),
+		gemHandler.DeleteGemImage,
+	)
+
+	gems.GET("/:id/certificates", certHandler.ListGemCertificates)
+
+	gems.POST("/:id/certificates",
+		middleware.PermissionMiddleware("gem:create"),
+		certHandler.RegisterCertificate,
+	)
+
+	gems.GET("/:id/provenance", provHandler.GetProvenance)
+
+	gems.POST("/:id/provenance",
+		middleware.PermissionMiddleware("gem:create"),
+		provHandler.AddProvenance,
+	)
+
+	// =====================================
+	// VOCABULARY ROUTES (gem attributes)
+	// =====================================
+	protected.GET("/vocabularies", vocabHandler.ListVocabularies)
+
+	// =====================================
+	// ME ROUTES (current user)
+	// =====================================
+	me := protected.Group("/me")
+
+	// buyers own the gems they won and can relist them
+	me.GET("/gems", gemHandler.ListMyGems)
+
+	me.GET("/seller-application", sellerAppHandler.GetMine)
+	me.POST("/seller-application", sellerAppHandler.Apply)
+
+	twoFactorHandler.RegisterRoutes(me.Group("/2fa"))
+	me.GET("/sessions", authHandler.LoginHistory)
+
+	profileHandler.RegisterRoutes(me)
+	me.GET("/kyc", kycHandler.GetMine)
+	me.POST("/kyc", kycHandler.Submit)
 
 	// =====================================
 	// AUCTION ROUTES
 	// =====================================
 	auctions := protected.Group("/auctions")
 
+	// sellers list their gems; buyers may only relist gems they won, which
+	// AuctionService checks along with ownership of the gem and auction
+	auctionWrite := middleware.AnyPermissionMiddleware("auction:create", "auction:relist")
+
 	auctions.POST("",
-		middleware.RoleMiddleware("SELLER", "ADMIN"),
+		auctionWrite,
 		auctionHandler.CreateAuction,
 	)
 
 	auctions.GET("", auctionHandler.GetAllAuctions)
 	auctions.GET("/:id", auctionHandler.GetAuctionByID)
+	auctions.GET("/:id/changes", auctionHandler.GetAuctionChanges)
+	auctions.GET("/:id/reserve", auctionHandler.GetAuctionReserve)
+
+	auctions.PATCH("/:id",
+		auctionWrite,
+		auctionHandler.UpdateAuction,
+	)
 
 	auctions.POST("/:id/start",
-		middleware.RoleMiddleware("SELLER", "ADMIN"),
+		auctionWrite,
 		auctionHandler.StartAuction,
 	)
 
 	auctions.POST("/:id/end",
-		middleware.RoleMiddleware("SELLER", "ADMIN"),
+		auctionWrite,
 		auctionHandler.EndAuction,
 	)
 
+	// the winner pays the seller directly; the seller confirms receipt
+	auctions.GET("/:id/payment", paymentHandler.GetAuctionPayment)
+	auctions.POST("/:id/payment/confirm", paymentHandler.ConfirmAuctionPayment)
+
+	// buyer and seller rate each other once the payment has completed
+	auctions.GET("/:id/reviews", reviewHandler.ListAuctionReviews)
+	auctions.POST("/:id/reviews", reviewHandler.CreateReview)
+
+	// =====================================
+	// REVIEW ROUTES
+	// =====================================
+	protected.GET("/users/:id/reviews", reviewHandler.ListUserReviews)
+	protected.POST("/reviews/:id/dispute", reviewHandler.DisputeReview)
+
 	// =====================================
 	// BIDDING ROUTES
 	// =====================================
 	bids := protected.Group("/bids")
 
 	bids.POST("",
-		middleware.RoleMiddleware("BUYER", "ADMIN"),
+		middleware.PermissionMiddleware("bid:place"),
 		bidHandler.PlaceBid,
 	)
 
@@ -168,6 +360,42 @@ func main() {
 	chat.POST("", chatHandler.SendChat)
 	chat.GET("/auction/:id", chatHandler.GetChatByAuction)
 
+	// =====================================
+	// ADMIN ROUTES
+	// =====================================
+	admin := protected.Group("/admin")
+
+	vocabAdmin := admin.Group("/vocabularies", middleware.PermissionMiddleware("vocabulary:manage"))
+	vocabAdmin.POST("", vocabHandler.CreateVocabulary)
+	vocabAdmin.PATCH("/:id", vocabHandler.UpdateVocabulary)
+
+	certAdmin := admin.Group("/certificates", middleware.PermissionMiddleware("certificate:review"))
+	certAdmin.GET("", certHandler.ListCertificatesForReview)
+	certAdmin.POST("/:id/review", certHandler.ReviewCertificate)
+
+	kycAdmin := admin.Group("/kyc", middleware.PermissionMiddleware("kyc:review"))
+	kycAdmin.GET("", kycHandler.ListForReview)
+	kycAdmin.POST("/:id/review", kycHandler.Review)
+	kycAdmin.GET("/:id/documents/:docId", kycHandler.DownloadDocument)
+
+	reviewAdmin := admin.Group("", middleware.PermissionMiddleware("review:moderate"))
+	reviewHandler.RegisterAdminRoutes(reviewAdmin)
+
+	monitorAdmin := admin.Group("", middleware.PermissionMiddleware("system:monitor"))
+	monitorAdmin.GET("/ws/stats", wsHandler.GetStats)
+
+	userAdmin := admin.Group("", middleware.PermissionMiddleware("user:manage"))
+	userAdmin.GET("/roles", roleHandler.ListRoles)
+	userAdmin.PUT("/roles/:role/2fa", roleHandler.SetRequire2FA)
+	userAdmin.POST("/users", authHandler.CreateUser)
+	userAdmin.PUT("/users/:id/roles", roleHandler.SetUserRoles)
+	userAdmin.POST("/users/:id/unlock", authHandler.UnlockUser)
+	adminUserHandler.RegisterRoutes(userAdmin)
+
+	userAdmin.GET("/seller-applications", sellerAppHandler.ListForReview)
+	userAdmin.POST("/seller-applications/:id/review", sellerAppHandler.Review)
+	userAdmin.GET("/seller-applications/:id/documents/:docId", sellerAppHandler.DownloadDocument)
+
 	// ===============================
 	// 🚀 Start Server
 	// ===============================
@@ -4,19 +4,63 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port        string
-	DBHost      string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBName      string
-	JWTSecret   string
-	MaxDBConns  int32
+	Port           string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	JWTSecret      string
+	MaxDBConns     int32
+	UploadDir      string
+	MediaURLPrefix string
+	MaxUploadBytes int64
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// TOTPEncryptionKey encrypts stored TOTP secrets; defaults to JWTSecret.
+	TOTPEncryptionKey string
+	TOTPIssuer        string
+
+	// MailDriver selects the mailer: "smtp", "file" (writes .eml files to
+	// MailDir) or "log".
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// AppBaseURL is the frontend origin used in links sent by email.
+	AppBaseURL string
+
+	// PrivateUploadDir holds documents that are never served statically,
+	// such as seller application paperwork.
+	PrivateUploadDir string
+
+	// Highest listing value (start or reserve price) a seller may list at
+	// each KYC level; business-verified sellers are not limited.
+	ListingLimitUnverified float64
+	ListingLimitIdentity   float64
+
+	// WSAllowedOrigins are the browser origins allowed to open WebSocket
+	// connections (WS_ALLOWED_ORIGINS, comma separated).
+	WSAllowedOrigins []string
+	// WSPubSub shares websocket events between server instances: "postgres"
+	// (LISTEN/NOTIFY) or empty for a single instance.
+	WSPubSub string
+
+	// TrustedProxies are the reverse proxies (IPs or CIDRs, TRUSTED_PROXIES
+	// comma separated) whose X-Forwarded-For is believed. Empty means the
+	// client IP is always the connection's remote address.
+	TrustedProxies []string
 }
 
 var AppConfig *Config
@@ -34,20 +78,82 @@ func LoadConfig() {
 		log.Fatal("Invalid DB_MAX_CONNS value")
 	}
 
+	maxUploadMB, err := strconv.Atoi(getEnv("MAX_UPLOAD_MB", "10"))
+	if err != nil || maxUploadMB <= 0 {
+		log.Fatal("Invalid MAX_UPLOAD_MB value")
+	}
+
+	accessTTL, err := time.ParseDuration(getEnv("ACCESS_TOKEN_TTL", "15m"))
+	if err != nil || accessTTL <= 0 {
+		log.Fatal("Invalid ACCESS_TOKEN_TTL value")
+	}
+
+	refreshTTL, err := time.ParseDuration(getEnv("REFRESH_TOKEN_TTL", "720h"))
+	if err != nil || refreshTTL <= 0 {
+		log.Fatal("Invalid REFRESH_TOKEN_TTL value")
+	}
+
+	limitUnverified, err := strconv.ParseFloat(getEnv("LISTING_LIMIT_UNVERIFIED", "1000"), 64)
+	if err != nil || limitUnverified < 0 {
+		log.Fatal("Invalid LISTING_LIMIT_UNVERIFIED value")
+	}
+
+	limitIdentity, err := strconv.ParseFloat(getEnv("LISTING_LIMIT_IDENTITY", "25000"), 64)
+	if err != nil || limitIdentity < limitUnverified {
+		log.Fatal("Invalid LISTING_LIMIT_IDENTITY value")
+	}
+
 	AppConfig = &Config{
-		Port:       getEnv("PORT", "8081"),
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", "gems_auction"),
-		JWTSecret:  getEnv("JWT_SECRET", "supersecret"),
-		MaxDBConns: int32(maxConns),
+		Port:           getEnv("PORT", "8081"),
+		DBHost:         getEnv("DB_HOST", "localhost"),
+		DBPort:         getEnv("DB_PORT", "5432"),
+		DBUser:         getEnv("DB_USER", "postgres"),
+		DBPassword:     getEnv("DB_PASSWORD", ""),
+		DBName:         getEnv("DB_NAME", "gems_auction"),
+		JWTSecret:      getEnv("JWT_SECRET", "supersecret"),
+		MaxDBConns:     int32(maxConns),
+		UploadDir:      getEnv("UPLOAD_DIR", "uploads"),
+		MediaURLPrefix: getEnv("MEDIA_URL_PREFIX", "/media"),
+		MaxUploadBytes: int64(maxUploadMB) << 20,
+
+		AccessTokenTTL:    accessTTL,
+		RefreshTokenTTL:   refreshTTL,
+		TOTPEncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", getEnv("JWT_SECRET", "supersecret")),
+		TOTPIssuer:        getEnv("TOTP_ISSUER", "Gems Auction"),
+		PrivateUploadDir:  getEnv("PRIVATE_UPLOAD_DIR", "private_uploads"),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "Gems Auction <no-reply@localhost>"),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		AppBaseURL:   getEnv("APP_BASE_URL", "http://localhost:5173"),
+
+		ListingLimitUnverified: limitUnverified,
+		ListingLimitIdentity:   limitIdentity,
+
+		WSAllowedOrigins: splitList(getEnv("WS_ALLOWED_ORIGINS", getEnv("APP_BASE_URL", "http://localhost:5173"))),
+		WSPubSub:         getEnv("WS_PUBSUB", ""),
+
+		TrustedProxies: splitList(getEnv("TRUSTED_PROXIES", "")),
 	}
 
 	log.Println("✅ Configuration Loaded Successfully")
 }
 
+// splitList splits a comma separated value, dropping empty entries.
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func getEnv(key, fallback string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Address is an entry in a user's address book. Winners pick one for
+// shipping.
+type Address struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	Label         string    `json:"label,omitempty"`
+	RecipientName string    `json:"recipient_name"`
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2,omitempty"`
+	City          string    `json:"city"`
+	Region        string    `json:"region,omitempty"`
+	PostalCode    string    `json:"postal_code,omitempty"`
+	Country       string    `json:"country"`
+	Phone         string    `json:"phone,omitempty"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
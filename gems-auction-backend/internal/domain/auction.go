@@ -11,15 +11,53 @@ const (
 )
 
 type Auction struct {
+	ID           int64         `json:"id"`
+	GemID        int64         `json:"gem_id"`
+	SellerID     int64         `json:"seller_id"`
+	StartPrice   float64       `json:"start_price"`
+	CurrentPrice float64       `json:"current_price"`
+	MinIncrement float64       `json:"min_increment"`
+	ReservePrice *float64      `json:"-"` // secret; see AuctionService.GetReserve
+	StartTime    time.Time     `json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+	Status       AuctionStatus `json:"status"`
+	WinnerID     *int64        `json:"winner_id,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+
+	// VerifiedCertificate is true when the gem has an admin-verified lab report.
+	VerifiedCertificate bool `json:"verified_certificate"`
+	// AllowSpectators lets visitors without an account watch the live feed.
+	AllowSpectators bool `json:"allow_spectators"`
+	// SellerRating aggregates the visible reviews the seller received as a
+	// seller.
+	SellerRating RatingSummary `json:"seller_rating"`
+	// Presence is who is watching the live feed; only set when a single
+	// auction is fetched.
+	Presence *AuctionPresence `json:"presence,omitempty"`
+}
+
+// AuctionPresence counts the live feed's audience across all server
+// instances. Only very large audiences, of hundreds of users on one
+// instance, may count a user connected to several instances more than once.
+type AuctionPresence struct {
+	// Viewers are signed-in users, each counted once however many
+	// connections they have open, on whichever instances.
+	Viewers int `json:"viewers"`
+	// Anonymous counts the connections of spectators without an account.
+	Anonymous int `json:"anonymous"`
+	// ActiveBidders are the viewers who bid in the last few minutes.
+	ActiveBidders int `json:"active_bidders"`
+}
+
+// AuctionChange is one field edit in an auction's public change history.
+type AuctionChange struct {
 	ID            int64         `json:"id"`
-	GemID         int64         `json:"gem_id"`
-	StartPrice    float64       `json:"start_price"`
-	CurrentPrice  float64       `json:"current_price"`
-	MinIncrement  float64       `json:"min_increment"`
-	StartTime     time.Time     `json:"start_time"`
-	EndTime       time.Time     `json:"end_time"`
-	Status        AuctionStatus `json:"status"`
-	WinnerID      *int64        `json:"winner_id,omitempty"`
+	AuctionID     int64         `json:"auction_id"`
+	ChangedBy     int64         `json:"changed_by"`
+	Field         string        `json:"field"`
+	OldValue      string        `json:"old_value"`
+	NewValue      string        `json:"new_value"`
+	AuctionStatus AuctionStatus `json:"auction_status"`
 	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
 }
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+type CertificateStatus string
+
+const (
+	CertificatePending  CertificateStatus = "PENDING"
+	CertificateVerified CertificateStatus = "VERIFIED"
+	CertificateRejected CertificateStatus = "REJECTED"
+)
+
+// Gemological laboratories whose reports can be registered.
+var CertificateLabs = []string{
+	"GIA", "GRS", "SSEF", "GUBELIN", "AGL", "NGJA", "IGI", "LOTUS", "GIT", "OTHER",
+}
+
+// GemCertificate is a lab report registered against a gem, together with
+// the attributes the report states and its admin verification state.
+type GemCertificate struct {
+	ID               int64             `json:"id"`
+	GemID            int64             `json:"gem_id"`
+	Lab              string            `json:"lab"`
+	ReportNumber     string            `json:"report_number"`
+	IssueDate        *time.Time        `json:"issue_date,omitempty"`
+	PDFKey           string            `json:"-"`
+	PDFURL           string            `json:"pdf_url,omitempty"`
+	StatedAttributes map[string]string `json:"stated_attributes"`
+	Status           CertificateStatus `json:"status"`
+	SubmittedBy      int64             `json:"submitted_by"`
+	ReviewedBy       *int64            `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time        `json:"reviewed_at,omitempty"`
+	ReviewNote       string            `json:"review_note,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
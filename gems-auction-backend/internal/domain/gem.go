@@ -8,20 +8,87 @@ const (
 	GemAvailable GemStatus = "AVAILABLE"
 	GemAuction   GemStatus = "AUCTION"
 	GemSold      GemStatus = "SOLD"
+	GemWithdrawn GemStatus = "WITHDRAWN"
 )
 
 type Gem struct {
-	ID           int64     `json:"id"`
-	SellerID     int64     `json:"seller_id"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description"`
-	Carat        float64   `json:"carat"`
-	Color        string    `json:"color"`
-	Clarity      string    `json:"clarity"`
-	Origin       string    `json:"origin"`
-	Certificate  string    `json:"certificate"` // certificate number
-	ImageURL     string    `json:"image_url"`
-	Status       GemStatus `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID          int64     `json:"id"`
+	SellerID    int64     `json:"seller_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Carat       float64   `json:"carat"`
+	Color       string    `json:"color"`
+	Clarity     string    `json:"clarity"`
+	Origin      string    `json:"origin"`
+	Certificate string    `json:"certificate"` // certificate number
+	ImageURL    string    `json:"image_url"`
+	Status      GemStatus `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	GemAttributes
+
+	Images []GemImage `json:"images,omitempty"`
+}
+
+// GemAttributes are the structured gemological fields. String values are
+// codes from the matching VocabularyKind; dimensions are in millimetres.
+type GemAttributes struct {
+	Species         string   `json:"species"`
+	Variety         string   `json:"variety"`
+	Treatment       string   `json:"treatment"`
+	Cut             string   `json:"cut"`
+	Shape           string   `json:"shape"`
+	LengthMM        *float64 `json:"length_mm,omitempty"`
+	WidthMM         *float64 `json:"width_mm,omitempty"`
+	DepthMM         *float64 `json:"depth_mm,omitempty"`
+	ColorHue        string   `json:"color_hue"`
+	ColorTone       string   `json:"color_tone"`
+	ColorSaturation string   `json:"color_saturation"`
+}
+
+// GemImage is one uploaded photo of a gem. Each upload is stored as the
+// original plus a web-sized copy and a thumbnail.
+type GemImage struct {
+	ID          int64     `json:"id"`
+	GemID       int64     `json:"gem_id"`
+	OriginalKey string    `json:"-"`
+	WebKey      string    `json:"-"`
+	ThumbKey    string    `json:"-"`
+	OriginalURL string    `json:"original_url"`
+	WebURL      string    `json:"web_url"`
+	ThumbURL    string    `json:"thumb_url"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Position    int       `json:"position"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GemEdit is one field change in a gem's edit log.
+type GemEdit struct {
+	ID        int64     `json:"id"`
+	GemID     int64     `json:"gem_id"`
+	EditedBy  int64     `json:"edited_by"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GemSearchHit is a gem matched by full-text search, with its rank and
+// highlighted fragments (<mark>...</mark>).
+type GemSearchHit struct {
+	Gem
+	Rank          float64 `json:"rank"`
+	NameHighlight string  `json:"name_highlight"`
+	Snippet       string  `json:"snippet"`
+}
+
+// FacetCount is the number of matching gems sharing one facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
 }
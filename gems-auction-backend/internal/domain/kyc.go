@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// KYCLevel is how far a user's identity has been verified. Listing limits
+// are keyed to it.
+type KYCLevel int
+
+const (
+	KYCUnverified KYCLevel = 0
+	KYCIdentity   KYCLevel = 1
+	KYCBusiness   KYCLevel = 2
+)
+
+type KYCStatus string
+
+const (
+	KYCPending  KYCStatus = "PENDING"
+	KYCApproved KYCStatus = "APPROVED"
+	KYCRejected KYCStatus = "REJECTED"
+)
+
+// Document types accepted with a KYC submission. Identity verification
+// needs one of KYCIdentityDocumentTypes.
+var (
+	KYCDocumentTypes = []string{
+		"PASSPORT", "NATIONAL_ID", "DRIVING_LICENSE", "PROOF_OF_ADDRESS",
+		"BUSINESS_REGISTRATION", "TAX_CERTIFICATE", "OTHER",
+	}
+	KYCIdentityDocumentTypes = []string{"PASSPORT", "NATIONAL_ID", "DRIVING_LICENSE"}
+)
+
+// KYCSubmission asks for the user to be verified up to Level. Approval
+// raises users.kyc_level.
+type KYCSubmission struct {
+	ID                 int64      `json:"id"`
+	UserID             int64      `json:"user_id"`
+	Level              KYCLevel   `json:"level"`
+	LegalName          string     `json:"legal_name"`
+	DateOfBirth        *time.Time `json:"date_of_birth,omitempty"`
+	BusinessName       string     `json:"business_name,omitempty"`
+	RegistrationNumber string     `json:"registration_number,omitempty"`
+	Status             KYCStatus  `json:"status"`
+	ReviewedBy         *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt         *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote         string     `json:"review_note,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	Documents []KYCDocument `json:"documents"`
+}
+
+// KYCDocument is a file attached to a KYC submission, kept in private
+// storage.
+type KYCDocument struct {
+	ID           int64     `json:"id"`
+	SubmissionID int64     `json:"submission_id"`
+	DocType      string    `json:"doc_type"`
+	FileName     string    `json:"file_name"`
+	Key          string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
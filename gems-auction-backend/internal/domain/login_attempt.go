@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type LoginResult string
+
+const (
+	LoginSuccess         LoginResult = "SUCCESS"
+	LoginMFAPending      LoginResult = "MFA_PENDING"
+	LoginBadPassword     LoginResult = "BAD_PASSWORD"
+	LoginUnknownUser     LoginResult = "UNKNOWN_USER"
+	LoginBadSecondFactor LoginResult = "BAD_SECOND_FACTOR"
+	LoginLocked          LoginResult = "LOCKED"
+	LoginThrottled       LoginResult = "THROTTLED"
+	LoginDisabled        LoginResult = "DISABLED"
+	LoginResetRequired   LoginResult = "RESET_REQUIRED"
+)
+
+// FailedLoginResults are the results that presented wrong credentials and
+// count towards throttling. Attempts refused because of an earlier lock do
+// not.
+var FailedLoginResults = []LoginResult{LoginBadPassword, LoginUnknownUser, LoginBadSecondFactor}
+
+// LoginAttempt is one entry of the login history.
+type LoginAttempt struct {
+	ID        int64       `json:"id"`
+	UserID    *int64      `json:"-"`
+	Email     string      `json:"-"`
+	IP        string      `json:"ip,omitempty"`
+	UserAgent string      `json:"user_agent,omitempty"`
+	Result    LoginResult `json:"result"`
+	CreatedAt time.Time   `json:"created_at"`
+}
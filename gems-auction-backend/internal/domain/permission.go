@@ -0,0 +1,45 @@
+package domain
+
+// Permission is a fine-grained capability granted to roles through the
+// role_permissions table.
+type Permission string
+
+const (
+	PermGemCreate         Permission = "gem:create"
+	PermGemModerate       Permission = "gem:moderate"
+	PermAuctionCreate     Permission = "auction:create"
+	PermAuctionModerate   Permission = "auction:moderate"
+	PermAuctionRelist     Permission = "auction:relist"
+	PermBidPlace          Permission = "bid:place"
+	PermCertificateReview Permission = "certificate:review"
+	PermVocabularyManage  Permission = "vocabulary:manage"
+	PermPaymentRefund     Permission = "payment:refund"
+	PermPaymentConfirm    Permission = "payment:confirm"
+	PermUserManage        Permission = "user:manage"
+	PermKYCReview         Permission = "kyc:review"
+	PermReviewModerate    Permission = "review:moderate"
+	PermSystemMonitor     Permission = "system:monitor"
+)
+
+// Role is a named bundle of permissions.
+type Role struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Require2FA  bool         `json:"require_2fa"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// PrimaryRole picks the role stored in users.role for display: the most
+// privileged of the given roles.
+func PrimaryRole(roles []UserRole) UserRole {
+	primary := RoleBuyer
+	for _, r := range roles {
+		switch {
+		case r == RoleAdmin:
+			return RoleAdmin
+		case r == RoleSeller:
+			primary = RoleSeller
+		}
+	}
+	return primary
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type ProvenanceEventType string
+
+const (
+	ProvenanceOriginClaim   ProvenanceEventType = "ORIGIN_CLAIM"
+	ProvenanceCutter        ProvenanceEventType = "CUTTER"
+	ProvenancePreviousOwner ProvenanceEventType = "PREVIOUS_OWNER"
+	ProvenancePlatformSale  ProvenanceEventType = "PLATFORM_SALE"
+	ProvenanceNote          ProvenanceEventType = "NOTE"
+)
+
+// ProvenanceEntry is one append-only record in a gem's history. Platform
+// sales are recorded automatically at settlement and carry the auction,
+// both parties and the hammer price.
+type ProvenanceEntry struct {
+	ID          int64               `json:"id"`
+	GemID       int64               `json:"gem_id"`
+	EventType   ProvenanceEventType `json:"event_type"`
+	PartyName   string              `json:"party_name,omitempty"`
+	Location    string              `json:"location,omitempty"`
+	Description string              `json:"description,omitempty"`
+	AuctionID   *int64              `json:"auction_id,omitempty"`
+	FromUserID  *int64              `json:"from_user_id,omitempty"`
+	ToUserID    *int64              `json:"to_user_id,omitempty"`
+	Amount      *float64            `json:"amount,omitempty"`
+	OccurredAt  *time.Time          `json:"occurred_at,omitempty"`
+	RecordedBy  *int64              `json:"recorded_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// ReviewRole is the part the reviewed user played in the sale.
+type ReviewRole string
+
+const (
+	ReviewOfSeller ReviewRole = "SELLER"
+	ReviewOfBuyer  ReviewRole = "BUYER"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewVisible ReviewStatus = "VISIBLE"
+	ReviewHidden  ReviewStatus = "HIDDEN"
+)
+
+// Review is one party's rating of the other after a settled auction. Each
+// party reviews an auction at most once; hidden reviews are left out of
+// listings and ratings.
+type Review struct {
+	ID             int64        `json:"id"`
+	AuctionID      int64        `json:"auction_id"`
+	ReviewerID     int64        `json:"reviewer_id"`
+	RevieweeID     int64        `json:"reviewee_id"`
+	RevieweeRole   ReviewRole   `json:"reviewee_role"`
+	Rating         int          `json:"rating"`
+	Comment        string       `json:"comment"`
+	Status         ReviewStatus `json:"status"`
+	ModeratedBy    *int64       `json:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time   `json:"moderated_at,omitempty"`
+	ModerationNote string       `json:"moderation_note,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// RatingSummary aggregates the visible reviews a user received in one role.
+// Average is 0 when Count is 0.
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type DisputeStatus string
+
+const (
+	DisputeOpen      DisputeStatus = "OPEN"
+	DisputeUpheld    DisputeStatus = "UPHELD"
+	DisputeDismissed DisputeStatus = "DISMISSED"
+)
+
+// ReviewDispute is a reviewee contesting a review. Upholding it hides the
+// review.
+type ReviewDispute struct {
+	ID         int64         `json:"id"`
+	ReviewID   int64         `json:"review_id"`
+	OpenedBy   int64         `json:"opened_by"`
+	Reason     string        `json:"reason"`
+	Status     DisputeStatus `json:"status"`
+	ResolvedBy *int64        `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time    `json:"resolved_at,omitempty"`
+	Response   string        `json:"response,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+type SellerApplicationStatus string
+
+const (
+	SellerApplicationPending  SellerApplicationStatus = "PENDING"
+	SellerApplicationApproved SellerApplicationStatus = "APPROVED"
+	SellerApplicationRejected SellerApplicationStatus = "REJECTED"
+)
+
+// Business types a seller can apply as.
+var SellerBusinessTypes = []string{"INDIVIDUAL", "COMPANY", "PARTNERSHIP"}
+
+// Document types accepted with a seller application.
+var SellerDocumentTypes = []string{
+	"BUSINESS_REGISTRATION", "TAX_CERTIFICATE", "IDENTITY", "TRADE_LICENSE", "OTHER",
+}
+
+// SellerApplication is a buyer's request to be granted the SELLER role,
+// reviewed by an admin.
+type SellerApplication struct {
+	ID                 int64                   `json:"id"`
+	UserID             int64                   `json:"user_id"`
+	BusinessName       string                  `json:"business_name"`
+	BusinessType       string                  `json:"business_type"`
+	RegistrationNumber string                  `json:"registration_number,omitempty"`
+	TaxID              string                  `json:"tax_id,omitempty"`
+	Phone              string                  `json:"phone"`
+	Address            string                  `json:"address"`
+	Website            string                  `json:"website,omitempty"`
+	Status             SellerApplicationStatus `json:"status"`
+	ReviewedBy         *int64                  `json:"reviewed_by,omitempty"`
+	ReviewedAt         *time.Time              `json:"reviewed_at,omitempty"`
+	ReviewNote         string                  `json:"review_note,omitempty"`
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+
+	Documents []SellerDocument `json:"documents"`
+}
+
+// SellerDocument is a file attached to a seller application. Documents are
+// kept in private storage and only downloadable by reviewers.
+type SellerDocument struct {
+	ID            int64     `json:"id"`
+	ApplicationID int64     `json:"application_id"`
+	DocType       string    `json:"doc_type"`
+	FileName      string    `json:"file_name"`
+	Key           string    `json:"-"`
+	ContentType   string    `json:"content_type"`
+	SizeBytes     int64     `json:"size_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// TwoFactorState is a user's TOTP enrolment. SecretEnc is set when setup
+// starts; EnabledAt once the user has confirmed a code.
+type TwoFactorState struct {
+	SecretEnc string
+	EnabledAt *time.Time
+	LastStep  *int64
+}
+
+func (s TwoFactorState) Enabled() bool {
+	return s.EnabledAt != nil
+}
+
+// TwoFactorStatus is what a user sees about their own enrolment.
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Required               bool       `json:"required"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
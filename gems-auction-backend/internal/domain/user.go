@@ -19,4 +19,82 @@ type User struct {
 	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// EmailVerifiedAt is nil until the user confirms their address.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// TokenVersion is embedded in access tokens; bumping it revokes them.
+	TokenVersion int `json:"-"`
+	// LockedUntil is set while the account is locked after repeated failed
+	// logins.
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	FailedLoginCount int        `json:"-"`
+
+	// PasswordResetRequired is set by an admin; the password stops working
+	// until the user resets it.
+	PasswordResetRequired bool `json:"password_reset_required,omitempty"`
+
+	Phone    string   `json:"phone,omitempty"`
+	TaxID    string   `json:"tax_id,omitempty"`
+	KYCLevel KYCLevel `json:"kyc_level"`
+
+	// Ratings received as a seller and as a buyer; only filled where a
+	// profile is shown.
+	SellerRating *RatingSummary `json:"seller_rating,omitempty"`
+	BuyerRating  *RatingSummary `json:"buyer_rating,omitempty"`
+
+	// Role above is the primary role kept for display; authorization uses
+	// Roles and the permissions they grant.
+	Roles       []UserRole   `json:"roles,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// RefreshToken is a stored (hashed) refresh token. Tokens rotated from the
+// same login share a FamilyID.
+type RefreshToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	FamilyID   string     `json:"-"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *int64     `json:"-"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type UserTokenPurpose string
+
+const (
+	TokenEmailVerify   UserTokenPurpose = "EMAIL_VERIFY"
+	TokenPasswordReset UserTokenPurpose = "PASSWORD_RESET"
+	TokenAccountUnlock UserTokenPurpose = "ACCOUNT_UNLOCK"
+)
+
+// UserToken is a single-use, expiring token mailed to a user.
+type UserToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   UserTokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// UserSummary is the per-user activity overview shown to user managers.
+type UserSummary struct {
+	UserID          int64      `json:"user_id"`
+	BidCount        int64      `json:"bid_count"`
+	AuctionsBidOn   int64      `json:"auctions_bid_on"`
+	LastBidAt       *time.Time `json:"last_bid_at,omitempty"`
+	WinCount        int64      `json:"win_count"`
+	WonValue        float64    `json:"won_value"`
+	ListingCount    int64      `json:"listing_count"`
+	OpenListings    int64      `json:"open_listings"`
+	SoldListings    int64      `json:"sold_listings"`
+	GemsOwned       int64      `json:"gems_owned"`
+	PaymentCount    int64      `json:"payment_count"`
+	PendingPayments int64      `json:"pending_payments"`
+	AmountPaid      float64    `json:"amount_paid"`
 }
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type VocabularyKind string
+
+const (
+	VocabSpecies         VocabularyKind = "SPECIES"
+	VocabVariety         VocabularyKind = "VARIETY"
+	VocabTreatment       VocabularyKind = "TREATMENT"
+	VocabCut             VocabularyKind = "CUT"
+	VocabShape           VocabularyKind = "SHAPE"
+	VocabColorHue        VocabularyKind = "COLOR_HUE"
+	VocabColorTone       VocabularyKind = "COLOR_TONE"
+	VocabColorSaturation VocabularyKind = "COLOR_SATURATION"
+)
+
+var VocabularyKinds = []VocabularyKind{
+	VocabSpecies,
+	VocabVariety,
+	VocabTreatment,
+	VocabCut,
+	VocabShape,
+	VocabColorHue,
+	VocabColorTone,
+	VocabColorSaturation,
+}
+
+// VocabularyTerm is one admin-managed value of a controlled gem attribute.
+// ParentCode links a VARIETY to its SPECIES.
+type VocabularyTerm struct {
+	ID         int64          `json:"id"`
+	Kind       VocabularyKind `json:"kind"`
+	Code       string         `json:"code"`
+	Label      string         `json:"label"`
+	ParentCode string         `json:"parent_code,omitempty"`
+	SortOrder  int            `json:"sort_order"`
+	IsActive   bool           `json:"is_active"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type AdminUserHandler struct {
+	adminUserService *service.AdminUserService
+}
+
+func NewAdminUserHandler(adminUserService *service.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{adminUserService: adminUserService}
+}
+
+// RegisterRoutes mounts the user management endpoints under /api/admin.
+// Role changes live at PUT /users/:id/roles (RoleHandler).
+func (h *AdminUserHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users", h.SearchUsers)
+	rg.GET("/users/:id", h.GetUser)
+	rg.GET("/users/:id/summary", h.GetSummary)
+	rg.POST("/users/:id/activate", h.Activate)
+	rg.POST("/users/:id/deactivate", h.Deactivate)
+	rg.POST("/users/:id/force-password-reset", h.ForcePasswordReset)
+}
+
+// SearchUsers lists users (GET /api/admin/users?q=&role=&active=&limit=&offset=).
+func (h *AdminUserHandler) SearchUsers(c *gin.Context) {
+	var req service.SearchUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.adminUserService.Search(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *AdminUserHandler) GetUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.adminUserService.GetUser(userID)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetSummary returns the user's bids, wins, listings and payments.
+func (h *AdminUserHandler) GetSummary(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := h.adminUserService.Summary(userID)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *AdminUserHandler) Activate(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// Deactivate disables the account and closes all of its sessions.
+func (h *AdminUserHandler) Deactivate(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+func (h *AdminUserHandler) setActive(c *gin.Context, active bool) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.adminUserService.SetActive(userID, currentUserID(c), hasRole(c, domain.RoleAdmin), active)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AdminUserHandler) ForcePasswordReset(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.adminUserService.ForcePasswordReset(userID, hasRole(c, domain.RoleAdmin)); err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset required; a reset link has been sent"})
+}
+
+func writeAdminUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case errors.Is(err, service.ErrAdminAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
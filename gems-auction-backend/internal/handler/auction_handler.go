@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/boswin/gems-auction-backend/internal/domain"
 	"github.com/boswin/gems-auction-backend/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 type AuctionHandler struct {
@@ -19,32 +21,36 @@ func NewAuctionHandler(auctionService *service.AuctionService) *AuctionHandler {
 
 func (h *AuctionHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("", h.CreateAuction)
+	rg.GET("", h.GetAllAuctions)
 	rg.GET("/:id", h.GetAuctionByID)
+	rg.PATCH("/:id", h.UpdateAuction)
+	rg.GET("/:id/changes", h.GetAuctionChanges)
+	rg.GET("/:id/reserve", h.GetAuctionReserve)
 	rg.POST("/:id/start", h.StartAuction)
 	rg.POST("/:id/end", h.EndAuction)
 }
 
 func (h *AuctionHandler) CreateAuction(c *gin.Context) {
-	// Optional role check
-	if v, ok := c.Get("role"); ok {
-		if roleStr, ok2 := v.(string); ok2 {
-			role := domain.UserRole(roleStr)
-			if role != domain.RoleSeller && role != domain.RoleAdmin {
-				c.JSON(http.StatusForbidden, gin.H{"error": "only SELLER/ADMIN can create auctions"})
-				return
-			}
-		}
-	}
-
 	var req service.CreateAuctionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	a, err := h.auctionService.Create(req)
+	a, err := h.auctionService.Create(req, currentUserID(c),
+		hasPermission(c, domain.PermAuctionModerate), hasPermission(c, domain.PermAuctionCreate))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "gem not found"})
+		case errors.Is(err, service.ErrGemNotOwner), errors.Is(err, service.ErrListingLimitExceeded),
+			errors.Is(err, service.ErrRelistOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrGemInAuction):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -66,60 +72,96 @@ func (h *AuctionHandler) GetAuctionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, a)
 }
 
-func (h *AuctionHandler) StartAuction(c *gin.Context) {
-	// Optional role check
-	if v, ok := c.Get("role"); ok {
-		if roleStr, ok2 := v.(string); ok2 {
-			role := domain.UserRole(roleStr)
-			if role != domain.RoleSeller && role != domain.RoleAdmin {
-				c.JSON(http.StatusForbidden, gin.H{"error": "only SELLER/ADMIN can start auctions"})
-				return
-			}
-		}
+func (h *AuctionHandler) UpdateAuction(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateAuctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
 	}
 
+	a, err := h.auctionService.UpdateAuction(auctionID, currentUserID(c), hasPermission(c, domain.PermAuctionModerate), req)
+	if err != nil {
+		writeAuctionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *AuctionHandler) GetAuctionChanges(c *gin.Context) {
 	auctionID, ok := parseIDParam(c, "id")
 	if !ok {
 		return
 	}
 
-	if err := h.auctionService.StartAuction(auctionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	changes, err := h.auctionService.GetChanges(auctionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "auction started"})
+	c.JSON(http.StatusOK, gin.H{"auction_id": auctionID, "changes": changes})
 }
 
-func (h *AuctionHandler) EndAuction(c *gin.Context) {
-	// Optional role check
-	if v, ok := c.Get("role"); ok {
-		if roleStr, ok2 := v.(string); ok2 {
-			role := domain.UserRole(roleStr)
-			if role != domain.RoleSeller && role != domain.RoleAdmin {
-				c.JSON(http.StatusForbidden, gin.H{"error": "only SELLER/ADMIN can end auctions"})
-				return
-			}
-		}
+// GetAuctionReserve shows the secret reserve to the seller.
+func (h *AuctionHandler) GetAuctionReserve(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
 	}
 
+	reserve, err := h.auctionService.GetReserve(auctionID, currentUserID(c), hasPermission(c, domain.PermAuctionModerate))
+	if err != nil {
+		writeAuctionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"auction_id": auctionID, "reserve_price": reserve})
+}
+
+func (h *AuctionHandler) StartAuction(c *gin.Context) {
 	auctionID, ok := parseIDParam(c, "id")
 	if !ok {
 		return
 	}
 
-	// winner_id is optional
-	var body struct {
-		WinnerID *int64 `json:"winner_id"`
+	if err := h.auctionService.StartAuction(auctionID, currentUserID(c), hasPermission(c, domain.PermAuctionModerate)); err != nil {
+		writeAuctionError(c, err)
+		return
 	}
-	_ = c.ShouldBindJSON(&body)
 
-	if err := h.auctionService.EndAuction(auctionID, body.WinnerID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "auction started"})
+}
+
+func (h *AuctionHandler) EndAuction(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "auction ended", "winner_id": body.WinnerID})
+	winnerID, err := h.auctionService.EndAuction(auctionID, currentUserID(c), hasPermission(c, domain.PermAuctionModerate))
+	if err != nil {
+		writeAuctionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "auction ended", "winner_id": winnerID})
+}
+
+func writeAuctionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "auction not found"})
+	case errors.Is(err, service.ErrAuctionNotOwner), errors.Is(err, service.ErrListingLimitExceeded):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
 }
 
 func parseIDParam(c *gin.Context, param string) (int64, bool) {
@@ -133,11 +175,16 @@ func parseIDParam(c *gin.Context, param string) (int64, bool) {
 }
 
 func (h *AuctionHandler) GetAllAuctions(c *gin.Context) {
-	auctions, err := h.auctionService.GetAllAuctions()
+	var req service.SearchAuctionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.auctionService.SearchAuctions(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, auctions)
+	c.JSON(http.StatusOK, page)
 }
-
@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/boswin/gems-auction-backend/internal/domain"
 	"github.com/boswin/gems-auction-backend/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 type AuthHandler struct {
@@ -18,6 +23,16 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/register", h.Register)
 	rg.POST("/login", h.Login)
+	rg.POST("/refresh", h.Refresh)
+	rg.POST("/logout", h.Logout)
+	rg.POST("/verify-email/request", h.RequestEmailVerification)
+	rg.POST("/verify-email/confirm", h.ConfirmEmail)
+	rg.POST("/password-reset/request", h.RequestPasswordReset)
+	rg.POST("/password-reset/confirm", h.ResetPassword)
+	rg.POST("/unlock/confirm", h.ConfirmUnlock)
+	rg.POST("/2fa/verify", h.VerifyTwoFactor)
+	rg.POST("/2fa/setup", h.SetupTwoFactor)
+	rg.POST("/2fa/enable", h.EnableTwoFactor)
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -29,6 +44,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	user, err := h.authService.Register(req)
 	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -36,6 +54,31 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "registered", "user": user})
 }
 
+// CreateUser lets a user manager create an account with any roles
+// (POST /api/admin/users). Only admins may create admins.
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req service.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := h.authService.CreateUser(req, hasRole(c, domain.RoleAdmin))
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrAdminRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req service.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -43,11 +86,249 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	res, err := h.authService.Login(req)
+	res, err := h.authService.Login(req, clientInfo(c))
+	if err != nil {
+		if writeThrottleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Refresh rotates a refresh token into a new token pair.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req service.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	res, err := h.authService.Refresh(req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Logout revokes the session of the given refresh token.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req service.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.Logout(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll ends every session of the current user, including outstanding
+// access tokens.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.authService.LogoutAll(currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out everywhere"})
+}
+
+// RequestEmailVerification always answers 202 so callers cannot tell
+// whether an address is registered.
+func (h *AuthHandler) RequestEmailVerification(c *gin.Context) {
+	var req service.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.RequestEmailVerification(req); err != nil {
+		log.Printf("email verification request failed: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the address is registered and unverified, a verification link has been sent"})
+}
+
+func (h *AuthHandler) ConfirmEmail(c *gin.Context) {
+	var req service.ConfirmTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.ConfirmEmail(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// RequestPasswordReset always answers 202 so callers cannot tell whether an
+// address is registered.
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req service.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(req); err != nil {
+		log.Printf("password reset request failed: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the address is registered, a reset link has been sent"})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.ResetPassword(req); err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+// VerifyTwoFactor exchanges a partial login token and a TOTP or recovery
+// code for a full session.
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req service.MFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	res, err := h.authService.VerifyTwoFactor(req, clientInfo(c))
 	if err != nil {
+		if writeThrottleError(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, res)
 }
+
+// SetupTwoFactor starts the enrolment a role requires before login can
+// finish.
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	var req service.MFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	setup, err := h.authService.SetupTwoFactor(req)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor confirms enrolment during login and returns the session
+// together with the recovery codes.
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req service.MFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	res, err := h.authService.EnableTwoFactor(req, clientInfo(c))
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ConfirmUnlock lifts a lockout with the token from the lockout email.
+func (h *AuthHandler) ConfirmUnlock(c *gin.Context) {
+	var req service.ConfirmTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.authService.ConfirmUnlock(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// UnlockUser lifts a lockout (POST /api/admin/users/:id/unlock).
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.UnlockUser(userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// LoginHistory lists the current user's recent login attempts
+// (GET /api/me/sessions).
+func (h *AuthHandler) LoginHistory(c *gin.Context) {
+	var req service.LoginHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	attempts, err := h.authService.LoginHistory(currentUserID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+// writeThrottleError answers 429 with Retry-After when err is a
+// *service.TooManyAttemptsError and reports whether it did.
+func writeThrottleError(c *gin.Context, err error) bool {
+	var terr *service.TooManyAttemptsError
+	if !errors.As(err, &terr) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(terr.RetryAfter().Seconds())))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": terr.Error(), "retry_at": terr.RetryAt, "locked": terr.Locked})
+	return true
+}
+
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
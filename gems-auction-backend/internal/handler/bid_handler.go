@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/boswin/gems-auction-backend/internal/service"
@@ -35,6 +36,10 @@ func (h *BidHandler) PlaceBid(c *gin.Context) {
 
 	bid, err := h.bidService.PlaceBid(req)
 	if err != nil {
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type CertificateHandler struct {
+	certService *service.CertificateService
+}
+
+func NewCertificateHandler(certService *service.CertificateService) *CertificateHandler {
+	return &CertificateHandler{certService: certService}
+}
+
+// RegisterCertificate accepts multipart/form-data with lab, report_number,
+// issue_date, stated_attributes (a JSON object) and an optional "report" PDF.
+// A plain JSON body without the PDF is accepted too.
+func (h *CertificateHandler) RegisterCertificate(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	limitUpload(c, h.certService.MaxUploadBytes())
+	var req service.RegisterCertificateRequest
+	if err := c.ShouldBind(&req); err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if raw := c.PostForm("stated_attributes"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.StatedAttributes); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stated_attributes must be a JSON object of strings"})
+			return
+		}
+	}
+
+	pdf, err := c.FormFile("report")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report upload"})
+		return
+	}
+
+	cert, err := h.certService.Register(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate), req, pdf)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCertificateTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			writeGemError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, cert)
+}
+
+func (h *CertificateHandler) ListGemCertificates(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	certs, err := h.certService.ListByGem(gemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "certificates": certs})
+}
+
+// ListCertificatesForReview is the admin queue (GET /api/admin/certificates?status=PENDING).
+func (h *CertificateHandler) ListCertificatesForReview(c *gin.Context) {
+	certs, err := h.certService.ListForReview(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"certificates": certs})
+}
+
+func (h *CertificateHandler) ReviewCertificate(c *gin.Context) {
+	certID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ReviewCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	cert, err := h.certService.Review(certID, currentUserID(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "certificate not found"})
+		case errors.Is(err, service.ErrCertificateTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, cert)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the user id set by AuthMiddleware (0 if missing).
+func currentUserID(c *gin.Context) int64 {
+	if v, ok := c.Get("user_id"); ok {
+		if id, ok2 := v.(int64); ok2 {
+			return id
+		}
+	}
+	return 0
+}
+
+// hasPermission reports whether the user's roles grant perm, based on the
+// permissions set by AuthMiddleware.
+func hasPermission(c *gin.Context, perm domain.Permission) bool {
+	for _, p := range c.GetStringSlice("permissions") {
+		if domain.Permission(p) == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRole reports whether the user holds role, based on the roles set by
+// AuthMiddleware.
+func hasRole(c *gin.Context, role domain.UserRole) bool {
+	for _, r := range c.GetStringSlice("roles") {
+		if domain.UserRole(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// writeValidationError responds with field-level errors when err is a
+// *service.ValidationError and reports whether it did.
+func writeValidationError(c *gin.Context, err error) bool {
+	var verr *service.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "fields": verr.Fields})
+	return true
+}
+
+// uploadFormOverhead allows for the form fields and part headers that come
+// with the files of an upload.
+const uploadFormOverhead = 1 << 20
+
+// limitUpload caps the request body before the multipart form is parsed,
+// so an oversized upload is refused while it is read instead of being
+// spooled to disk first. maxFilesBytes is the most the files may add up to.
+func limitUpload(c *gin.Context, maxFilesBytes int64) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFilesBytes+uploadFormOverhead)
+}
+
+// writeUploadTooLarge responds 413 when err came from a body over the
+// limitUpload cap and reports whether it did.
+func writeUploadTooLarge(c *gin.Context, err error) bool {
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		return false
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload too large"})
+	return true
+}
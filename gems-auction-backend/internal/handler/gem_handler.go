@@ -1,25 +1,37 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/boswin/gems-auction-backend/internal/domain"
 	"github.com/boswin/gems-auction-backend/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 type GemHandler struct {
-	gemService *service.GemService
+	gemService   *service.GemService
+	imageService *service.GemImageService
 }
 
-func NewGemHandler(gemService *service.GemService) *GemHandler {
-	return &GemHandler{gemService: gemService}
+func NewGemHandler(gemService *service.GemService, imageService *service.GemImageService) *GemHandler {
+	return &GemHandler{gemService: gemService, imageService: imageService}
 }
 
 func (h *GemHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("", h.CreateGem)
+	rg.GET("/search", h.SearchGems)
 	rg.GET("/:id", h.GetGemByID)
+	rg.PATCH("/:id", h.UpdateGem)
+	rg.POST("/:id/withdraw", h.WithdrawGem)
+	rg.GET("/:id/edits", h.GetGemEdits)
+	rg.GET("/:id/images", h.ListGemImages)
+	rg.POST("/:id/images", h.UploadGemImages)
+	rg.PUT("/:id/images/order", h.ReorderGemImages)
+	rg.POST("/:id/images/:imageId/primary", h.SetPrimaryGemImage)
+	rg.DELETE("/:id/images/:imageId", h.DeleteGemImage)
 }
 
 func (h *GemHandler) CreateGem(c *gin.Context) {
@@ -36,16 +48,6 @@ func (h *GemHandler) CreateGem(c *gin.Context) {
 		}
 	}
 
-	// (Optional) role check if middleware sets role
-	if v, ok := c.Get("role"); ok {
-		if roleStr, ok2 := v.(string); ok2 {
-			if domain.UserRole(roleStr) != domain.RoleSeller && domain.UserRole(roleStr) != domain.RoleAdmin {
-				c.JSON(http.StatusForbidden, gin.H{"error": "only SELLER/ADMIN can create gems"})
-				return
-			}
-		}
-	}
-
 	gem, err := h.gemService.Create(req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -69,5 +71,202 @@ func (h *GemHandler) GetGemByID(c *gin.Context) {
 		return
 	}
 
+	if err := h.imageService.Attach(gem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gem)
+}
+
+func (h *GemHandler) SearchGems(c *gin.Context) {
+	var req service.SearchGemsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	res, err := h.gemService.Search(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ListMyGems returns the caller's inventory (GET /api/me/gems?status=).
+func (h *GemHandler) ListMyGems(c *gin.Context) {
+	gems, err := h.gemService.ListBySeller(currentUserID(c), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gems": gems})
+}
+
+func (h *GemHandler) UpdateGem(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateGemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	gem, err := h.gemService.Update(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate), req)
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gem)
+}
+
+func (h *GemHandler) WithdrawGem(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	gem, err := h.gemService.Withdraw(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate))
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gem)
 }
+
+func (h *GemHandler) GetGemEdits(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	edits, err := h.gemService.GetEdits(gemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "edits": edits})
+}
+
+func (h *GemHandler) ListGemImages(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	images, err := h.imageService.List(gemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "images": images})
+}
+
+// UploadGemImages accepts multipart/form-data with one or more "images" files.
+func (h *GemHandler) UploadGemImages(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	limitUpload(c, h.imageService.MaxUploadBytes())
+	form, err := c.MultipartForm()
+	if err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with images required"})
+		return
+	}
+
+	images, err := h.imageService.Upload(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate), form.File["images"])
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"gem_id": gemID, "images": images})
+}
+
+func (h *GemHandler) ReorderGemImages(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		ImageIDs []int64 `json:"image_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	images, err := h.imageService.Reorder(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate), body.ImageIDs)
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "images": images})
+}
+
+func (h *GemHandler) SetPrimaryGemImage(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	imageID, ok := parseIDParam(c, "imageId")
+	if !ok {
+		return
+	}
+
+	images, err := h.imageService.SetPrimary(gemID, imageID, currentUserID(c), hasPermission(c, domain.PermGemModerate))
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "images": images})
+}
+
+func (h *GemHandler) DeleteGemImage(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	imageID, ok := parseIDParam(c, "imageId")
+	if !ok {
+		return
+	}
+
+	if err := h.imageService.Delete(gemID, imageID, currentUserID(c), hasPermission(c, domain.PermGemModerate)); err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "image deleted"})
+}
+
+func writeGemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "gem not found"})
+	case errors.Is(err, service.ErrGemNotOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrGemInAuction):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
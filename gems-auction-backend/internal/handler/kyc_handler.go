@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type KYCHandler struct {
+	kycService *service.KYCService
+}
+
+func NewKYCHandler(kycService *service.KYCService) *KYCHandler {
+	return &KYCHandler{kycService: kycService}
+}
+
+// Submit accepts multipart/form-data with the verification details, one or
+// more "documents" files and a matching "document_types" value per file.
+func (h *KYCHandler) Submit(c *gin.Context) {
+	var req service.KYCRequest
+	limitUpload(c, h.kycService.MaxUploadBytes())
+	if err := c.ShouldBind(&req); err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected multipart/form-data"})
+		return
+	}
+
+	k, err := h.kycService.Submit(currentUserID(c), req, form.File["documents"])
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrKYCPending), errors.Is(err, service.ErrKYCLevelReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, k)
+}
+
+// GetMine returns the current user's KYC level, listing limit and latest
+// submission.
+func (h *KYCHandler) GetMine(c *gin.Context) {
+	o, err := h.kycService.GetMine(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, o)
+}
+
+// ListForReview is the admin queue (GET /api/admin/kyc?status=PENDING).
+func (h *KYCHandler) ListForReview(c *gin.Context) {
+	subs, err := h.kycService.ListForReview(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": subs})
+}
+
+func (h *KYCHandler) Review(c *gin.Context) {
+	submissionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ReviewKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	k, err := h.kycService.Review(submissionID, currentUserID(c), req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pending submission not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, k)
+}
+
+// DownloadDocument streams a document from private storage to a reviewer.
+func (h *KYCHandler) DownloadDocument(c *gin.Context) {
+	submissionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	docID, ok := parseIDParam(c, "docId")
+	if !ok {
+		return
+	}
+
+	doc, rc, err := h.kycService.OpenDocument(submissionID, docID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, rc, nil)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+// GetAuctionPayment shows the winner's payment to the buyer and seller.
+func (h *PaymentHandler) GetAuctionPayment(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	p, err := h.paymentService.GetForAuction(auctionID, currentUserID(c), hasPermission(c, domain.PermPaymentConfirm))
+	if err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// ConfirmAuctionPayment lets the seller confirm the winner has paid, with an
+// optional reference such as a bank transfer id.
+func (h *PaymentHandler) ConfirmAuctionPayment(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Reference string `json:"reference"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	p, err := h.paymentService.ConfirmForAuction(auctionID, currentUserID(c), hasPermission(c, domain.PermPaymentConfirm), body.Reference)
+	if err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func writePaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+	case errors.Is(err, service.ErrPaymentNotParty), errors.Is(err, service.ErrPaymentNotSeller):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPaymentNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type ProfileHandler struct {
+	profileService *service.ProfileService
+}
+
+func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+// RegisterRoutes mounts the profile and address book of the current user.
+func (h *ProfileHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/profile", h.GetProfile)
+	rg.PATCH("/profile", h.UpdateProfile)
+	rg.GET("/addresses", h.ListAddresses)
+	rg.POST("/addresses", h.CreateAddress)
+	rg.PUT("/addresses/:id", h.UpdateAddress)
+	rg.DELETE("/addresses/:id", h.DeleteAddress)
+}
+
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	user, err := h.profileService.GetProfile(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	var req service.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := h.profileService.UpdateProfile(currentUserID(c), req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *ProfileHandler) ListAddresses(c *gin.Context) {
+	addresses, err := h.profileService.ListAddresses(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+}
+
+func (h *ProfileHandler) CreateAddress(c *gin.Context) {
+	var req service.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	a, err := h.profileService.CreateAddress(currentUserID(c), req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, a)
+}
+
+func (h *ProfileHandler) UpdateAddress(c *gin.Context) {
+	addressID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	a, err := h.profileService.UpdateAddress(currentUserID(c), addressID, req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		writeAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *ProfileHandler) DeleteAddress(c *gin.Context) {
+	addressID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.profileService.DeleteAddress(currentUserID(c), addressID); err != nil {
+		writeAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "address deleted"})
+}
+
+func writeAddressError(c *gin.Context, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
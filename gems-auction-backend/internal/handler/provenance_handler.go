@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ProvenanceHandler struct {
+	provService *service.ProvenanceService
+}
+
+func NewProvenanceHandler(provService *service.ProvenanceService) *ProvenanceHandler {
+	return &ProvenanceHandler{provService: provService}
+}
+
+func (h *ProvenanceHandler) GetProvenance(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	entries, err := h.provService.List(gemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gem_id": gemID, "provenance": entries})
+}
+
+func (h *ProvenanceHandler) AddProvenance(c *gin.Context) {
+	gemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.AddProvenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	entry, err := h.provService.Add(gemID, currentUserID(c), hasPermission(c, domain.PermGemModerate), req)
+	if err != nil {
+		writeGemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
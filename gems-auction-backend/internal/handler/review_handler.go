@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+}
+
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// CreateReview rates the other party of a settled auction.
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	rv, err := h.reviewService.Create(auctionID, currentUserID(c), req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "auction not found"})
+		case errors.Is(err, service.ErrReviewNotParty):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrSaleNotSettled), errors.Is(err, service.ErrAlreadyReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, rv)
+}
+
+func (h *ReviewHandler) ListAuctionReviews(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	reviews, err := h.reviewService.ListForAuction(auctionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews})
+}
+
+// ListUserReviews is a user's public reputation
+// (GET /api/users/:id/reviews?role=SELLER).
+func (h *ReviewHandler) ListUserReviews(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ListReviewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	out, err := h.reviewService.ListForUser(userID, req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// DisputeReview lets the reviewed user contest a review.
+func (h *ReviewHandler) DisputeReview(c *gin.Context) {
+	reviewID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.DisputeReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	d, err := h.reviewService.Dispute(reviewID, currentUserID(c), req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		case errors.Is(err, service.ErrNotReviewee):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrDisputeOpen):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, d)
+}
+
+// RegisterAdminRoutes mounts review moderation and the dispute queue.
+func (h *ReviewHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/reviews", h.SearchReviews)
+	rg.POST("/reviews/:id/moderate", h.ModerateReview)
+	rg.GET("/review-disputes", h.ListDisputes)
+	rg.POST("/review-disputes/:id/resolve", h.ResolveDispute)
+}
+
+// SearchReviews lists all reviews, hidden ones included
+// (GET /api/admin/reviews?status=HIDDEN).
+func (h *ReviewHandler) SearchReviews(c *gin.Context) {
+	var req service.ListReviewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := h.reviewService.Search(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	reviewID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	rv, err := h.reviewService.Moderate(reviewID, currentUserID(c), req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
+// ListDisputes is the admin queue (GET /api/admin/review-disputes?status=OPEN).
+func (h *ReviewHandler) ListDisputes(c *gin.Context) {
+	disputes, err := h.reviewService.ListDisputes(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"disputes": disputes})
+}
+
+func (h *ReviewHandler) ResolveDispute(c *gin.Context) {
+	disputeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	d, err := h.reviewService.ResolveDispute(disputeID, currentUserID(c), req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "open dispute not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type SellerApplicationHandler struct {
+	appService *service.SellerApplicationService
+}
+
+func NewSellerApplicationHandler(appService *service.SellerApplicationService) *SellerApplicationHandler {
+	return &SellerApplicationHandler{appService: appService}
+}
+
+// Apply accepts multipart/form-data with the business details, one or more
+// "documents" files and a matching "document_types" value per file.
+func (h *SellerApplicationHandler) Apply(c *gin.Context) {
+	var req service.SellerApplicationRequest
+	limitUpload(c, h.appService.MaxUploadBytes())
+	if err := c.ShouldBind(&req); err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		if writeUploadTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected multipart/form-data"})
+		return
+	}
+
+	app, err := h.appService.Apply(currentUserID(c), req, form.File["documents"])
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrApplicationPending), errors.Is(err, service.ErrAlreadySeller):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, app)
+}
+
+func (h *SellerApplicationHandler) GetMine(c *gin.Context) {
+	app, err := h.appService.GetMine(currentUserID(c))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no seller application"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+// ListForReview is the admin queue (GET /api/admin/seller-applications?status=PENDING).
+func (h *SellerApplicationHandler) ListForReview(c *gin.Context) {
+	apps, err := h.appService.ListForReview(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applications": apps})
+}
+
+func (h *SellerApplicationHandler) Review(c *gin.Context) {
+	appID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.ReviewSellerApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	app, err := h.appService.Review(appID, currentUserID(c), req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pending application not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+// DownloadDocument streams a document from private storage to a reviewer.
+func (h *SellerApplicationHandler) DownloadDocument(c *gin.Context) {
+	appID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	docID, ok := parseIDParam(c, "docId")
+	if !ok {
+		return
+	}
+
+	doc, rc, err := h.appService.OpenDocument(appID, docID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, rc, nil)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type VocabularyHandler struct {
+	vocabService *service.VocabularyService
+}
+
+func NewVocabularyHandler(vocabService *service.VocabularyService) *VocabularyHandler {
+	return &VocabularyHandler{vocabService: vocabService}
+}
+
+// ListVocabularies returns active terms, optionally for one kind
+// (GET /api/vocabularies?kind=SPECIES). Users with vocabulary:manage may pass
+// include_inactive=true.
+func (h *VocabularyHandler) ListVocabularies(c *gin.Context) {
+	includeInactive := c.Query("include_inactive") == "true" && hasPermission(c, domain.PermVocabularyManage)
+
+	terms, err := h.vocabService.List(c.Query("kind"), includeInactive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"terms": terms})
+}
+
+func (h *VocabularyHandler) CreateVocabulary(c *gin.Context) {
+	var req service.CreateVocabularyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	t, err := h.vocabService.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+func (h *VocabularyHandler) UpdateVocabulary(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateVocabularyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	t, err := h.vocabService.Update(id, req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "vocabulary term not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
@@ -1,27 +1,45 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/service"
+	"github.com/boswin/gems-auction-backend/internal/websocket"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 // WSManager is implemented by your websocket hub layer.
-// We'll implement this in internal/websocket later.
 type WSManager interface {
-	ServeAuctionWS(c *gin.Context, auctionID int64)
+	ServeAuctionWS(c *gin.Context, auctionID int64, session websocket.Session, backend websocket.Backend)
+	Stats() websocket.HubStats
 }
 
 type WebSocketHandler struct {
-	ws WSManager
+	ws             WSManager
+	auctionService *service.AuctionService
+	backend        *wsBackend
 }
 
-func NewWebSocketHandler(ws WSManager) *WebSocketHandler {
-	return &WebSocketHandler{ws: ws}
+func NewWebSocketHandler(
+	ws WSManager,
+	auctionService *service.AuctionService,
+	bidService *service.BidService,
+	chatService *service.ChatService,
+) *WebSocketHandler {
+	return &WebSocketHandler{
+		ws:             ws,
+		auctionService: auctionService,
+		backend:        &wsBackend{auctionService: auctionService, bidService: bidService, chatService: chatService},
+	}
 }
 
-func (h *WebSocketHandler) RegisterRoutes(r *gin.Engine) {
+// RegisterRoutes mounts the WebSocket endpoint. r should authenticate the
+// handshake with middleware.WebSocketAuthMiddleware.
+func (h *WebSocketHandler) RegisterRoutes(r *gin.RouterGroup) {
 	// WebSocket endpoint (not inside /api usually)
 	r.GET("/ws/auction/:id", h.HandleAuctionWS)
 }
@@ -34,5 +52,73 @@ func (h *WebSocketHandler) HandleAuctionWS(c *gin.Context) {
 		return
 	}
 
-	h.ws.ServeAuctionWS(c, auctionID)
+	a, err := h.auctionService.GetByID(auctionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auction not found"})
+		return
+	}
+
+	session := websocket.Session{
+		UserID:      currentUserID(c),
+		ExpiresAt:   c.GetTime("token_expires_at"),
+		Subprotocol: c.GetString("ws_subprotocol"),
+		Permissions: c.GetStringSlice("permissions"),
+	}
+	// a reconnecting client resumes after the last event it saw, given by
+	// its seq and epoch
+	if v := c.Query("last_seq"); v != "" {
+		seq, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last_seq"})
+			return
+		}
+		session.LastSeq = &seq
+		session.Epoch = c.Query("epoch")
+	}
+
+	if session.UserID == 0 && !a.AllowSpectators {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "sign in to watch this auction"})
+		return
+	}
+
+	h.ws.ServeAuctionWS(c, auctionID, session, h.backend)
+}
+
+// GetStats reports the rooms and connections of this server instance.
+func (h *WebSocketHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ws.Stats())
+}
+
+// wsBackend runs WebSocket commands through the same services, and the
+// same checks, as the REST endpoints.
+type wsBackend struct {
+	auctionService *service.AuctionService
+	bidService     *service.BidService
+	chatService    *service.ChatService
+}
+
+func (w *wsBackend) PlaceBid(session websocket.Session, auctionID int64, amount float64) (any, error) {
+	if session.UserID == 0 {
+		return nil, errors.New("sign in to bid")
+	}
+	if !session.HasPermission(string(domain.PermBidPlace)) {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	bid, err := w.bidService.PlaceBid(service.PlaceBidRequest{AuctionID: auctionID, UserID: session.UserID, Amount: amount})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("auction not found")
+	}
+	return bid, err
+}
+
+func (w *wsBackend) SendChat(session websocket.Session, auctionID int64, message string) (any, error) {
+	if session.UserID == 0 {
+		return nil, errors.New("sign in to chat")
+	}
+	return w.chatService.Send(service.SendChatRequest{AuctionID: auctionID, UserID: session.UserID, Message: message})
+}
+
+func (w *wsBackend) AuctionSnapshot(auctionID int64) (any, error) {
+	return w.auctionService.Snapshot(auctionID)
 }
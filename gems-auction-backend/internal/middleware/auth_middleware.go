@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,7 +10,13 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// SessionValidator confirms that a token's user is still active and that
+// the token has not been revoked.
+type SessionValidator interface {
+	ValidateSession(userID int64, tokenVersion int) error
+}
+
+func AuthMiddleware(sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
@@ -18,44 +25,87 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenStr := strings.TrimPrefix(auth, "Bearer ")
-
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
-			// Ensure signing method is HMAC
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
-
-		if err != nil || token == nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		claims, err := parseAccessToken(strings.TrimPrefix(auth, "Bearer "), sessions)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
-		}
+		setIdentity(c, claims)
+		c.Next()
+	}
+}
 
-		// sub is user id (float64 in MapClaims)
-		sub, ok := claims["sub"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
-			c.Abort()
-			return
+// parseAccessToken verifies a full access token and that its session is
+// still valid. Error messages are safe to return to the client.
+func parseAccessToken(tokenStr string, sessions SessionValidator) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
+		// Ensure signing method is HMAC
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
 
-		email, _ := claims["email"].(string)
-		role, _ := claims["role"].(string)
+	if err != nil || token == nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
 
-		c.Set("user_id", int64(sub))
-		c.Set("email", email)
-		c.Set("role", role)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
 
-		c.Next()
+	// partial tokens from a login waiting for its second factor are only
+	// good for the /auth/2fa endpoints
+	if _, partial := claims["mfa"]; partial {
+		return nil, errors.New("two-factor authentication required")
+	}
+
+	// sub is user id (float64 in MapClaims)
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, errors.New("invalid token subject")
+	}
+
+	// tokens issued before versioning carry no "ver" and count as 0
+	ver, _ := claims["ver"].(float64)
+	if err := sessions.ValidateSession(int64(sub), int(ver)); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// setIdentity stores the token's user, roles and permissions on the
+// request context.
+func setIdentity(c *gin.Context, claims jwt.MapClaims) {
+	sub, _ := claims["sub"].(float64)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+
+	roles := claimStrings(claims["roles"])
+	if len(roles) == 0 && role != "" {
+		// tokens issued before multi-role support carry only "role"
+		roles = []string{role}
+	}
+
+	c.Set("user_id", int64(sub))
+	c.Set("email", email)
+	c.Set("role", role)
+	c.Set("roles", roles)
+	c.Set("permissions", claimStrings(claims["perms"]))
+}
+
+// claimStrings converts a JSON array claim into a string slice.
+func claimStrings(v any) []string {
+	items, _ := v.([]any)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
 	}
+	return out
 }
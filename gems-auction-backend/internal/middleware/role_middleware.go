@@ -6,6 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// RoleMiddleware allows the request when the user holds any of the allowed
+// roles.
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	allowed := make(map[string]bool, len(allowedRoles))
 	for _, r := range allowedRoles {
@@ -13,26 +15,60 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
-		roleVal, ok := c.Get("role")
-		if !ok {
+		roles := c.GetStringSlice("roles")
+		if len(roles) == 0 {
 			c.JSON(http.StatusForbidden, gin.H{"error": "missing role"})
 			c.Abort()
 			return
 		}
 
-		role, ok := roleVal.(string)
-		if !ok || role == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
-			c.Abort()
-			return
+		for _, role := range roles {
+			if allowed[role] {
+				c.Next()
+				return
+			}
 		}
 
-		if !allowed[role] {
-			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
-			c.Abort()
-			return
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		c.Abort()
+	}
+}
+
+// PermissionMiddleware allows the request only when the user's roles grant
+// every one of the required permissions.
+func PermissionMiddleware(required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := make(map[string]bool)
+		for _, p := range c.GetStringSlice("permissions") {
+			granted[p] = true
+		}
+
+		for _, p := range required {
+			if !granted[p] {
+				c.JSON(http.StatusForbidden, gin.H{"error": "missing permission: " + p})
+				c.Abort()
+				return
+			}
 		}
 
 		c.Next()
 	}
 }
+
+// AnyPermissionMiddleware allows the request when the user's roles grant at
+// least one of the permissions.
+func AnyPermissionMiddleware(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range c.GetStringSlice("permissions") {
+			for _, want := range perms {
+				if p == want {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		c.Abort()
+	}
+}
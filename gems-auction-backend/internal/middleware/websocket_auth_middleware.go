@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenProtocol is the WebSocket subprotocol marker for passing the
+// access token: browsers cannot set headers on a WebSocket handshake, so
+// clients may offer the protocols ["access_token", <token>] instead.
+const AccessTokenProtocol = "access_token"
+
+// WebSocketAuthMiddleware authenticates a WebSocket handshake. The access
+// token is taken from the Authorization header, the access_token query
+// parameter, the access_token subprotocol or the access_token cookie, in
+// that order. Handshakes without a token continue anonymously; an invalid
+// token is rejected. On success it also sets "token_expires_at" and, when
+// the token came as a subprotocol, "ws_subprotocol".
+func WebSocketAuthMiddleware(sessions SessionValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenStr, subprotocol := handshakeToken(c)
+		if tokenStr == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := parseAccessToken(tokenStr, sessions)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		setIdentity(c, claims)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("token_expires_at", exp.Time)
+		}
+		if subprotocol != "" {
+			c.Set("ws_subprotocol", subprotocol)
+		}
+
+		c.Next()
+	}
+}
+
+// handshakeToken finds the access token of a handshake and, when it was
+// passed as a subprotocol, the protocol to echo back.
+func handshakeToken(c *gin.Context) (token, subprotocol string) {
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer "), ""
+	}
+
+	if token := c.Query("access_token"); token != "" {
+		return token, ""
+	}
+
+	var protocols []string
+	for _, p := range strings.Split(c.GetHeader("Sec-WebSocket-Protocol"), ",") {
+		protocols = append(protocols, strings.TrimSpace(p))
+	}
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == AccessTokenProtocol && protocols[i+1] != "" {
+			return protocols[i+1], AccessTokenProtocol
+		}
+	}
+
+	if cookie, err := c.Cookie("access_token"); err == nil && cookie != "" {
+		return cookie, ""
+	}
+
+	return "", ""
+}
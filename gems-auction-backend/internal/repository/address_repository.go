@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type AddressRepository struct{}
+
+func NewAddressRepository() *AddressRepository {
+	return &AddressRepository{}
+}
+
+const addressColumns = `id,user_id,COALESCE(label,''),recipient_name,line1,COALESCE(line2,''),city,COALESCE(region,''),` +
+	`COALESCE(postal_code,''),country,COALESCE(phone,''),is_default,created_at,updated_at`
+
+func scanAddress(row rowScanner, a *domain.Address) error {
+	return row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.Label,
+		&a.RecipientName,
+		&a.Line1,
+		&a.Line2,
+		&a.City,
+		&a.Region,
+		&a.PostalCode,
+		&a.Country,
+		&a.Phone,
+		&a.IsDefault,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+}
+
+// ListByUser returns the user's addresses, default first.
+func (r *AddressRepository) ListByUser(userID int64) ([]domain.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM user_addresses WHERE user_id=$1 ORDER BY is_default DESC, id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []domain.Address{}
+	for rows.Next() {
+		var a domain.Address
+		if err := scanAddress(rows, &a); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+// GetByID returns one of the user's addresses; other users' addresses are
+// reported as pgx.ErrNoRows.
+func (r *AddressRepository) GetByID(userID, id int64) (*domain.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM user_addresses WHERE id=$1 AND user_id=$2`
+
+	var a domain.Address
+	if err := scanAddress(config.DB.QueryRow(context.Background(), query, id, userID), &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create adds an address. The user's first address always becomes the
+// default.
+func (r *AddressRepository) Create(a *domain.Address) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM user_addresses WHERE user_id=$1`, a.UserID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		a.IsDefault = true
+	}
+	if a.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, a.UserID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	query := `
+		INSERT INTO user_addresses (user_id,label,recipient_name,line1,line2,city,region,postal_code,country,phone,is_default,created_at,updated_at)
+		VALUES ($1,NULLIF($2,''),$3,$4,NULLIF($5,''),$6,NULLIF($7,''),NULLIF($8,''),$9,NULLIF($10,''),$11,$12,$12)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query,
+		a.UserID, a.Label, a.RecipientName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone, a.IsDefault, now,
+	).Scan(&a.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Update replaces the fields of an address. Setting IsDefault moves the
+// default to it; clearing it on the current default is ignored so the user
+// keeps a default.
+func (r *AddressRepository) Update(a *domain.Address) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if a.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, a.UserID); err != nil {
+			return err
+		}
+	}
+
+	a.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE user_addresses
+		SET label=NULLIF($1,''), recipient_name=$2, line1=$3, line2=NULLIF($4,''), city=$5, region=NULLIF($6,''),
+		    postal_code=NULLIF($7,''), country=$8, phone=NULLIF($9,''), is_default = is_default OR $10, updated_at=$11
+		WHERE id=$12 AND user_id=$13
+		RETURNING is_default
+	`
+	if err := tx.QueryRow(ctx, query,
+		a.Label, a.RecipientName, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone, a.IsDefault, a.UpdatedAt,
+		a.ID, a.UserID,
+	).Scan(&a.IsDefault); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Delete removes an address. When it was the default, the oldest remaining
+// address takes over.
+func (r *AddressRepository) Delete(userID, id int64) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var wasDefault bool
+	err = tx.QueryRow(ctx, `DELETE FROM user_addresses WHERE id=$1 AND user_id=$2 RETURNING is_default`, id, userID).Scan(&wasDefault)
+	if err != nil {
+		return err
+	}
+
+	if wasDefault {
+		promote := `
+			UPDATE user_addresses SET is_default=TRUE, updated_at=$1
+			WHERE id = (SELECT id FROM user_addresses WHERE user_id=$2 ORDER BY id ASC LIMIT 1)
+		`
+		if _, err := tx.Exec(ctx, promote, time.Now(), userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, userID int64) error {
+	_, err := tx.Exec(ctx, `UPDATE user_addresses SET is_default=FALSE WHERE user_id=$1 AND is_default`, userID)
+	return err
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
@@ -14,71 +16,235 @@ func NewAuctionRepository() *AuctionRepository {
 	return &AuctionRepository{}
 }
 
-func (r *AuctionRepository) Create(a *domain.Auction) error {
-	query := `
-		INSERT INTO auctions (gem_id,start_price,current_price,min_increment,start_time,end_time,status,created_at,updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-		RETURNING id
-	`
-
-	now := time.Now()
-
-	return config.DB.QueryRow(context.Background(), query,
-		a.GemID,
-		a.StartPrice,
-		a.CurrentPrice,
-		a.MinIncrement,
-		a.StartTime,
-		a.EndTime,
-		a.Status,
-		now,
-		now,
-	).Scan(&a.ID)
-}
-
 func (r *AuctionRepository) UpdateCurrentPrice(id int64, price float64) error {
 	query := `UPDATE auctions SET current_price=$1, updated_at=$2 WHERE id=$3`
 	_, err := config.DB.Exec(context.Background(), query, price, time.Now(), id)
 	return err
 }
 
-func (r *AuctionRepository) GetAll() ([]domain.Auction, error) {
-	query := `
-		SELECT id, gem_id, start_price, current_price, min_increment,
-		       start_time, end_time, status, created_at, updated_at
-		FROM auctions
-		ORDER BY created_at DESC
-	`
+// VerifiedCertificateSQL is true when the auction's gem has a verified lab
+// report. It expects the auctions table to be aliased as "a".
+const VerifiedCertificateSQL = `EXISTS (SELECT 1 FROM gem_certificates gc WHERE gc.gem_id = a.gem_id AND gc.status = 'VERIFIED')`
+
+// SellerRatingSQL selects the average and number of visible reviews an
+// auction's seller received as a seller. It expects the auctions table to be
+// aliased as "a".
+const SellerRatingSQL = `(SELECT COALESCE(AVG(rv.rating), 0)::float8 FROM reviews rv
+	  WHERE rv.reviewee_id = a.seller_id AND rv.reviewee_role = 'SELLER' AND rv.status = 'VISIBLE'),
+	 (SELECT COUNT(*) FROM reviews rv
+	  WHERE rv.reviewee_id = a.seller_id AND rv.reviewee_role = 'SELLER' AND rv.status = 'VISIBLE')`
+
+// AuctionSort describes one catalog ordering: the sort column and direction.
+// Every ordering is tie-broken on a.id so keyset cursors stay stable.
+type AuctionSort struct {
+	Column string
+	Desc   bool
+}
+
+var AuctionSorts = map[string]AuctionSort{
+	"ending_soon": {Column: "a.end_time", Desc: false},
+	"price_asc":   {Column: "a.current_price", Desc: false},
+	"price_desc":  {Column: "a.current_price", Desc: true},
+	"newest":      {Column: "a.created_at", Desc: true},
+}
+
+// AuctionCursor marks the last row of a page. Value holds the sort column
+// value of that row (time.Time or float64 depending on the sort).
+type AuctionCursor struct {
+	Value any
+	ID    int64
+}
+
+type AuctionFilter struct {
+	Statuses     []domain.AuctionStatus
+	MinPrice     *float64
+	MaxPrice     *float64
+	EndsAfter    *time.Time
+	EndsBefore   *time.Time
+	MinCarat     *float64
+	MaxCarat     *float64
+	Color        string
+	Clarity      string
+	Origin       string
+	Species      string
+	Variety      string
+	Treatment    string
+	ColorHue     string
+	VerifiedOnly bool
+	Sort         AuctionSort
+	After        *AuctionCursor
+	Limit        int
+}
+
+// Search returns one page of auctions matching the filter together with the
+// total number of matches (ignoring the cursor).
+func (r *AuctionRepository) Search(f AuctionFilter) ([]domain.Auction, int64, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, len(f.Statuses))
+		for i, st := range f.Statuses {
+			statuses[i] = string(st)
+		}
+		where = append(where, "a.status = ANY("+arg(statuses)+")")
+	}
+	if f.MinPrice != nil {
+		where = append(where, "a.current_price >= "+arg(*f.MinPrice))
+	}
+	if f.MaxPrice != nil {
+		where = append(where, "a.current_price <= "+arg(*f.MaxPrice))
+	}
+	if f.EndsAfter != nil {
+		where = append(where, "a.end_time >= "+arg(*f.EndsAfter))
+	}
+	if f.EndsBefore != nil {
+		where = append(where, "a.end_time <= "+arg(*f.EndsBefore))
+	}
+	if f.MinCarat != nil {
+		where = append(where, "g.carat >= "+arg(*f.MinCarat))
+	}
+	if f.MaxCarat != nil {
+		where = append(where, "g.carat <= "+arg(*f.MaxCarat))
+	}
+	if f.Color != "" {
+		where = append(where, "LOWER(g.color) = LOWER("+arg(f.Color)+")")
+	}
+	if f.Clarity != "" {
+		where = append(where, "LOWER(g.clarity) = LOWER("+arg(f.Clarity)+")")
+	}
+	if f.Origin != "" {
+		where = append(where, "LOWER(g.origin) = LOWER("+arg(f.Origin)+")")
+	}
+	if f.Species != "" {
+		where = append(where, "g.species = "+arg(f.Species))
+	}
+	if f.Variety != "" {
+		where = append(where, "g.variety = "+arg(f.Variety))
+	}
+	if f.Treatment != "" {
+		where = append(where, "g.treatment = "+arg(f.Treatment))
+	}
+	if f.ColorHue != "" {
+		where = append(where, "g.color_hue = "+arg(f.ColorHue))
+	}
+
+	if f.VerifiedOnly {
+		where = append(where, VerifiedCertificateSQL)
+	}
+
+	from := ` FROM auctions a JOIN gems g ON g.id = a.gem_id`
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
 
-	rows, err := config.DB.Query(context.Background(), query)
+	ctx := context.Background()
+
+	var total int64
+	if err := config.DB.QueryRow(ctx, "SELECT COUNT(*)"+from+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	cmp, dir := ">", "ASC"
+	if f.Sort.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if f.After != nil {
+		keyset := "(" + f.Sort.Column + ", a.id) " + cmp + " (" + arg(f.After.Value) + ", " + arg(f.After.ID) + ")"
+		if whereSQL == "" {
+			whereSQL = " WHERE " + keyset
+		} else {
+			whereSQL += " AND " + keyset
+		}
+	}
+
+	query := `SELECT a.id, a.gem_id, a.seller_id, a.start_price, a.current_price, a.min_increment, a.reserve_price,
+	                 a.start_time, a.end_time, a.status, a.winner_id, a.allow_spectators, a.created_at, a.updated_at, ` +
+		VerifiedCertificateSQL + ", " + SellerRatingSQL +
+		from + whereSQL +
+		" ORDER BY " + f.Sort.Column + " " + dir + ", a.id " + dir +
+		" LIMIT " + arg(f.Limit)
+
+	rows, err := config.DB.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var auctions []domain.Auction
+	auctions := []domain.Auction{}
 
 	for rows.Next() {
 		var a domain.Auction
 		err := rows.Scan(
 			&a.ID,
 			&a.GemID,
+			&a.SellerID,
 			&a.StartPrice,
 			&a.CurrentPrice,
 			&a.MinIncrement,
+			&a.ReservePrice,
 			&a.StartTime,
 			&a.EndTime,
 			&a.Status,
+			&a.WinnerID,
+			&a.AllowSpectators,
 			&a.CreatedAt,
 			&a.UpdatedAt,
+			&a.VerifiedCertificate,
+			&a.SellerRating.Average,
+			&a.SellerRating.Count,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		auctions = append(auctions, a)
 	}
 
-	return auctions, nil
+	return auctions, total, rows.Err()
 }
 
+// GetChanges returns the edit history of an auction, oldest first.
+func (r *AuctionRepository) GetChanges(auctionID int64) ([]domain.AuctionChange, error) {
+	query := `
+		SELECT id, auction_id, changed_by, field, COALESCE(old_value,''), COALESCE(new_value,''), auction_status, created_at
+		FROM auction_changes
+		WHERE auction_id=$1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []domain.AuctionChange{}
+
+	for rows.Next() {
+		var ch domain.AuctionChange
+		err := rows.Scan(
+			&ch.ID,
+			&ch.AuctionID,
+			&ch.ChangedBy,
+			&ch.Field,
+			&ch.OldValue,
+			&ch.NewValue,
+			&ch.AuctionStatus,
+			&ch.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, ch)
+	}
 
+	return changes, rows.Err()
+}
@@ -54,3 +54,39 @@ func (r *BidRepository) GetHighestBid(auctionID int64) (*domain.Bid, error) {
 
 	return &bid, nil
 }
+
+// GetRecent returns the latest bids on an auction, newest first.
+func (r *BidRepository) GetRecent(auctionID int64, limit int) ([]domain.Bid, error) {
+	query := `
+		SELECT id,auction_id,user_id,amount,created_at
+		FROM bids
+		WHERE auction_id=$1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, auctionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bids := []domain.Bid{}
+
+	for rows.Next() {
+		var bid domain.Bid
+		err := rows.Scan(
+			&bid.ID,
+			&bid.AuctionID,
+			&bid.UserID,
+			&bid.Amount,
+			&bid.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bids = append(bids, bid)
+	}
+
+	return bids, rows.Err()
+}
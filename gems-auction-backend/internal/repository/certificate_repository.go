@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+type CertificateRepository struct{}
+
+func NewCertificateRepository() *CertificateRepository {
+	return &CertificateRepository{}
+}
+
+const certificateColumns = `id,gem_id,lab,report_number,issue_date,COALESCE(pdf_key,''),stated_attributes,status,` +
+	`submitted_by,reviewed_by,reviewed_at,COALESCE(review_note,''),created_at,updated_at`
+
+func scanCertificate(row rowScanner, c *domain.GemCertificate) error {
+	return row.Scan(
+		&c.ID,
+		&c.GemID,
+		&c.Lab,
+		&c.ReportNumber,
+		&c.IssueDate,
+		&c.PDFKey,
+		&c.StatedAttributes,
+		&c.Status,
+		&c.SubmittedBy,
+		&c.ReviewedBy,
+		&c.ReviewedAt,
+		&c.ReviewNote,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+func (r *CertificateRepository) Create(c *domain.GemCertificate) error {
+	query := `
+		INSERT INTO gem_certificates (gem_id,lab,report_number,issue_date,pdf_key,stated_attributes,status,submitted_by,created_at,updated_at)
+		VALUES ($1,$2,$3,$4,NULLIF($5,''),$6,$7,$8,$9,$10)
+		RETURNING id
+	`
+
+	now := time.Now()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+
+	return config.DB.QueryRow(context.Background(), query,
+		c.GemID,
+		c.Lab,
+		c.ReportNumber,
+		c.IssueDate,
+		c.PDFKey,
+		c.StatedAttributes,
+		c.Status,
+		c.SubmittedBy,
+		now,
+		now,
+	).Scan(&c.ID)
+}
+
+func (r *CertificateRepository) GetByID(id int64) (*domain.GemCertificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM gem_certificates WHERE id=$1`
+
+	var c domain.GemCertificate
+	if err := scanCertificate(config.DB.QueryRow(context.Background(), query, id), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CertificateRepository) GetByGem(gemID int64) ([]domain.GemCertificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM gem_certificates WHERE gem_id=$1 ORDER BY created_at DESC, id DESC`
+	return r.list(query, gemID)
+}
+
+// GetByStatus returns certificates awaiting (or past) review, oldest first.
+func (r *CertificateRepository) GetByStatus(status domain.CertificateStatus) ([]domain.GemCertificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM gem_certificates WHERE status=$1 ORDER BY created_at ASC, id ASC`
+	return r.list(query, status)
+}
+
+func (r *CertificateRepository) UpdateReview(c *domain.GemCertificate) error {
+	query := `
+		UPDATE gem_certificates
+		SET status=$1, reviewed_by=$2, reviewed_at=$3, review_note=NULLIF($4,''), updated_at=$5
+		WHERE id=$6
+	`
+
+	c.UpdatedAt = time.Now()
+
+	_, err := config.DB.Exec(context.Background(), query,
+		c.Status,
+		c.ReviewedBy,
+		c.ReviewedAt,
+		c.ReviewNote,
+		c.UpdatedAt,
+		c.ID,
+	)
+	return err
+}
+
+func (r *CertificateRepository) list(query string, args ...any) ([]domain.GemCertificate, error) {
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certs := []domain.GemCertificate{}
+
+	for rows.Next() {
+		var c domain.GemCertificate
+		if err := scanCertificate(rows, &c); err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+
+	return certs, rows.Err()
+}
@@ -62,3 +62,43 @@ func (r *ChatRepository) GetByAuction(auctionID int64) ([]domain.ChatMessage, er
 
 	return messages, nil
 }
+
+// GetRecent returns the latest messages of an auction's chat, oldest first.
+func (r *ChatRepository) GetRecent(auctionID int64, limit int) ([]domain.ChatMessage, error) {
+	query := `
+		SELECT id,auction_id,user_id,message,created_at
+		FROM (
+			SELECT id,auction_id,user_id,message,created_at
+			FROM chat_messages
+			WHERE auction_id=$1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		) recent
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, auctionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []domain.ChatMessage{}
+
+	for rows.Next() {
+		var msg domain.ChatMessage
+		err := rows.Scan(
+			&msg.ID,
+			&msg.AuctionID,
+			&msg.UserID,
+			&msg.Message,
+			&msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
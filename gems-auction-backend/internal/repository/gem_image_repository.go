@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type GemImageRepository struct{}
+
+func NewGemImageRepository() *GemImageRepository {
+	return &GemImageRepository{}
+}
+
+const gemImageColumns = `id,gem_id,original_key,web_key,thumb_key,content_type,size_bytes,width,height,position,is_primary,created_at`
+
+func scanGemImage(row rowScanner, img *domain.GemImage) error {
+	return row.Scan(
+		&img.ID,
+		&img.GemID,
+		&img.OriginalKey,
+		&img.WebKey,
+		&img.ThumbKey,
+		&img.ContentType,
+		&img.SizeBytes,
+		&img.Width,
+		&img.Height,
+		&img.Position,
+		&img.IsPrimary,
+		&img.CreatedAt,
+	)
+}
+
+// Create appends an image after the gem's existing images. The first image
+// of a gem becomes its primary image.
+func (r *GemImageRepository) Create(img *domain.GemImage) error {
+	query := `
+		INSERT INTO gem_images (gem_id,original_key,web_key,thumb_key,content_type,size_bytes,width,height,position,is_primary,created_at)
+		SELECT $1,$2,$3,$4,$5,$6,$7,$8,
+		       COALESCE((SELECT MAX(position)+1 FROM gem_images WHERE gem_id=$1), 0),
+		       NOT EXISTS (SELECT 1 FROM gem_images WHERE gem_id=$1 AND is_primary),
+		       $9
+		RETURNING id, position, is_primary
+	`
+
+	img.CreatedAt = time.Now()
+
+	return config.DB.QueryRow(context.Background(), query,
+		img.GemID,
+		img.OriginalKey,
+		img.WebKey,
+		img.ThumbKey,
+		img.ContentType,
+		img.SizeBytes,
+		img.Width,
+		img.Height,
+		img.CreatedAt,
+	).Scan(&img.ID, &img.Position, &img.IsPrimary)
+}
+
+func (r *GemImageRepository) GetByID(id int64) (*domain.GemImage, error) {
+	query := `SELECT ` + gemImageColumns + ` FROM gem_images WHERE id=$1`
+
+	var img domain.GemImage
+	if err := scanGemImage(config.DB.QueryRow(context.Background(), query, id), &img); err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// GetByGem returns a gem's images in display order.
+func (r *GemImageRepository) GetByGem(gemID int64) ([]domain.GemImage, error) {
+	query := `SELECT ` + gemImageColumns + ` FROM gem_images WHERE gem_id=$1 ORDER BY position ASC, id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, gemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []domain.GemImage{}
+
+	for rows.Next() {
+		var img domain.GemImage
+		if err := scanGemImage(rows, &img); err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+
+	return images, rows.Err()
+}
+
+// Delete removes an image. If it was the primary image, the first remaining
+// image is promoted.
+func (r *GemImageRepository) Delete(gemID, imageID int64) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var wasPrimary bool
+	if err := tx.QueryRow(ctx,
+		`DELETE FROM gem_images WHERE id=$1 AND gem_id=$2 RETURNING is_primary`, imageID, gemID,
+	).Scan(&wasPrimary); err != nil {
+		return err
+	}
+
+	if wasPrimary {
+		promote := `
+			UPDATE gem_images SET is_primary=TRUE
+			WHERE id = (SELECT id FROM gem_images WHERE gem_id=$1 ORDER BY position ASC, id ASC LIMIT 1)
+		`
+		if _, err := tx.Exec(ctx, promote, gemID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetPrimary makes imageID the gem's only primary image.
+func (r *GemImageRepository) SetPrimary(gemID, imageID int64) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// clear first: the partial unique index allows one primary per gem
+	if _, err := tx.Exec(ctx, `UPDATE gem_images SET is_primary=FALSE WHERE gem_id=$1 AND is_primary`, gemID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE gem_images SET is_primary=TRUE WHERE id=$1 AND gem_id=$2`, imageID, gemID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reorder sets image positions to follow the order of imageIDs.
+func (r *GemImageRepository) Reorder(gemID int64, imageIDs []int64) error {
+	query := `
+		UPDATE gem_images g SET position = o.ord - 1
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, ord)
+		WHERE g.id = o.id AND g.gem_id = $1
+	`
+	_, err := config.DB.Exec(context.Background(), query, gemID, imageIDs)
+	return err
+}
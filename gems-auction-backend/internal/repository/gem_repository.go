@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
 )
 
 type GemRepository struct{}
@@ -14,10 +17,53 @@ func NewGemRepository() *GemRepository {
 	return &GemRepository{}
 }
 
+const gemColumns = `id,seller_id,name,description,carat,color,clarity,origin,certificate,image_url,status,created_at,updated_at,` +
+	`COALESCE(species,''),COALESCE(variety,''),COALESCE(treatment,''),COALESCE(cut,''),COALESCE(shape,''),` +
+	`length_mm,width_mm,depth_mm,COALESCE(color_hue,''),COALESCE(color_tone,''),COALESCE(color_saturation,'')`
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanGem scans gemColumns, followed by any extra destinations.
+func scanGem(row rowScanner, gem *domain.Gem, extra ...any) error {
+	dest := []any{
+		&gem.ID,
+		&gem.SellerID,
+		&gem.Name,
+		&gem.Description,
+		&gem.Carat,
+		&gem.Color,
+		&gem.Clarity,
+		&gem.Origin,
+		&gem.Certificate,
+		&gem.ImageURL,
+		&gem.Status,
+		&gem.CreatedAt,
+		&gem.UpdatedAt,
+		&gem.Species,
+		&gem.Variety,
+		&gem.Treatment,
+		&gem.Cut,
+		&gem.Shape,
+		&gem.LengthMM,
+		&gem.WidthMM,
+		&gem.DepthMM,
+		&gem.ColorHue,
+		&gem.ColorTone,
+		&gem.ColorSaturation,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
 func (r *GemRepository) Create(gem *domain.Gem) error {
 	query := `
-		INSERT INTO gems (seller_id,name,description,carat,color,clarity,origin,certificate,image_url,status,created_at,updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+		INSERT INTO gems (seller_id,name,description,carat,color,clarity,origin,certificate,image_url,status,created_at,updated_at,
+		                  species,variety,treatment,cut,shape,length_mm,width_mm,depth_mm,color_hue,color_tone,color_saturation)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,
+		        NULLIF($13,''),NULLIF($14,''),NULLIF($15,''),NULLIF($16,''),NULLIF($17,''),$18,$19,$20,
+		        NULLIF($21,''),NULLIF($22,''),NULLIF($23,''))
 		RETURNING id
 	`
 
@@ -36,33 +82,252 @@ func (r *GemRepository) Create(gem *domain.Gem) error {
 		gem.Status,
 		now,
 		now,
+		gem.Species,
+		gem.Variety,
+		gem.Treatment,
+		gem.Cut,
+		gem.Shape,
+		gem.LengthMM,
+		gem.WidthMM,
+		gem.DepthMM,
+		gem.ColorHue,
+		gem.ColorTone,
+		gem.ColorSaturation,
 	).Scan(&gem.ID)
 }
 
 func (r *GemRepository) GetByID(id int64) (*domain.Gem, error) {
-	query := `SELECT id,seller_id,name,description,carat,color,clarity,origin,certificate,image_url,status,created_at,updated_at FROM gems WHERE id=$1`
+	query := `SELECT ` + gemColumns + ` FROM gems WHERE id=$1`
 
 	var gem domain.Gem
 
-	err := config.DB.QueryRow(context.Background(), query, id).Scan(
-		&gem.ID,
-		&gem.SellerID,
-		&gem.Name,
-		&gem.Description,
-		&gem.Carat,
-		&gem.Color,
-		&gem.Clarity,
-		&gem.Origin,
-		&gem.Certificate,
-		&gem.ImageURL,
-		&gem.Status,
-		&gem.CreatedAt,
-		&gem.UpdatedAt,
-	)
+	if err := scanGem(config.DB.QueryRow(context.Background(), query, id), &gem); err != nil {
+		return nil, err
+	}
+
+	return &gem, nil
+}
+
+// HasAuctionInStatus reports whether any auction of the gem is in one of the
+// given statuses.
+func (r *GemRepository) HasAuctionInStatus(gemID int64, statuses ...domain.AuctionStatus) (bool, error) {
+	sts := make([]string, len(statuses))
+	for i, st := range statuses {
+		sts[i] = string(st)
+	}
+
+	var exists bool
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM auctions WHERE gem_id=$1 AND status = ANY($2))`, gemID, sts,
+	).Scan(&exists)
+	return exists, err
+}
+
+// SetImageURL points the gem's image_url at its primary image and records
+// the change in the edit log.
+func (r *GemRepository) SetImageURL(gemID, editedBy int64, url string) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var old string
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(image_url,'') FROM gems WHERE id=$1 FOR UPDATE`, gemID).Scan(&old); err != nil {
+		return err
+	}
+	if old == url {
+		return nil
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `UPDATE gems SET image_url=$1, updated_at=$2 WHERE id=$3`, url, now, gemID); err != nil {
+		return err
+	}
+
+	ins := `INSERT INTO gem_edits (gem_id, edited_by, field, old_value, new_value, created_at)
+	        VALUES ($1,$2,'image_url',$3,$4,$5)`
+	if _, err := tx.Exec(ctx, ins, gemID, editedBy, old, url, now); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetBySeller lists a seller's inventory, optionally limited to one status.
+func (r *GemRepository) GetBySeller(sellerID int64, status domain.GemStatus) ([]domain.Gem, error) {
+	query := `SELECT ` + gemColumns + ` FROM gems WHERE seller_id=$1 AND ($2 = '' OR status=$2) ORDER BY updated_at DESC, id DESC`
 
+	rows, err := config.DB.Query(context.Background(), query, sellerID, string(status))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &gem, nil
+	gems := []domain.Gem{}
+
+	for rows.Next() {
+		var gem domain.Gem
+		if err := scanGem(rows, &gem); err != nil {
+			return nil, err
+		}
+		gems = append(gems, gem)
+	}
+
+	return gems, rows.Err()
+}
+
+// GetEdits returns the edit log of a gem, oldest first.
+func (r *GemRepository) GetEdits(gemID int64) ([]domain.GemEdit, error) {
+	query := `
+		SELECT id, gem_id, edited_by, field, COALESCE(old_value,''), COALESCE(new_value,''), created_at
+		FROM gem_edits
+		WHERE gem_id=$1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, gemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []domain.GemEdit{}
+
+	for rows.Next() {
+		var e domain.GemEdit
+		err := rows.Scan(
+			&e.ID,
+			&e.GemID,
+			&e.EditedBy,
+			&e.Field,
+			&e.OldValue,
+			&e.NewValue,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, e)
+	}
+
+	return edits, rows.Err()
+}
+
+// GemSearchFilter narrows a full-text search by exact facet values.
+type GemSearchFilter struct {
+	Query   string
+	Origin  string
+	Color   string
+	Clarity string
+	Limit   int
+	Offset  int
+}
+
+// Search runs a ranked full-text query over gems.search_vector and returns the
+// requested page of hits plus the total number of matches.
+func (r *GemRepository) Search(f GemSearchFilter) ([]domain.GemSearchHit, int64, error) {
+	args := []any{f.Query}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	// withdrawn gems are off the market
+	where := []string{"search_vector @@ q", "status <> " + arg(domain.GemWithdrawn)}
+	if f.Origin != "" {
+		where = append(where, "LOWER(origin) = LOWER("+arg(f.Origin)+")")
+	}
+	if f.Color != "" {
+		where = append(where, "LOWER(color) = LOWER("+arg(f.Color)+")")
+	}
+	if f.Clarity != "" {
+		where = append(where, "LOWER(clarity) = LOWER("+arg(f.Clarity)+")")
+	}
+
+	from := ` FROM gems, websearch_to_tsquery('english', $1) AS q WHERE ` + strings.Join(where, " AND ")
+
+	ctx := context.Background()
+
+	var total int64
+	if err := config.DB.QueryRow(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// name and description are seller input, so they are HTML-escaped
+	// before <mark> is added around the matches
+	query := `SELECT ` + gemColumns + `,
+	                 ts_rank_cd(search_vector, q) AS rank,
+	                 ts_headline('english', ` + htmlEscapeSQL("name") + `, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+	                 ts_headline('english', ` + htmlEscapeSQL("COALESCE(description, '')") + `, q,
+	                             'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8')` +
+		from +
+		` ORDER BY rank DESC, id DESC LIMIT ` + arg(f.Limit) + ` OFFSET ` + arg(f.Offset)
+
+	rows, err := config.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []domain.GemSearchHit{}
+
+	for rows.Next() {
+		var hit domain.GemSearchHit
+		if err := scanGem(rows, &hit.Gem, &hit.Rank, &hit.NameHighlight, &hit.Snippet); err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, total, rows.Err()
+}
+
+// htmlEscapeSQL wraps the text expression expr so it is escaped for HTML.
+func htmlEscapeSQL(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+}
+
+// SearchFacets counts the gems matching a full-text query by origin, color
+// and clarity.
+func (r *GemRepository) SearchFacets(query string) (map[string][]domain.FacetCount, error) {
+	q := `
+		WITH matched AS (
+			SELECT origin, color, clarity
+			FROM gems, websearch_to_tsquery('english', $1) AS q
+			WHERE search_vector @@ q AND status <> $2
+		)
+		SELECT 'origin', origin, COUNT(*) FROM matched WHERE COALESCE(origin, '') <> '' GROUP BY origin
+		UNION ALL
+		SELECT 'color', color, COUNT(*) FROM matched WHERE COALESCE(color, '') <> '' GROUP BY color
+		UNION ALL
+		SELECT 'clarity', clarity, COUNT(*) FROM matched WHERE COALESCE(clarity, '') <> '' GROUP BY clarity
+		ORDER BY 1, 3 DESC, 2
+	`
+
+	rows, err := config.DB.Query(context.Background(), q, query, domain.GemWithdrawn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := map[string][]domain.FacetCount{
+		"origin":  {},
+		"color":   {},
+		"clarity": {},
+	}
+
+	for rows.Next() {
+		var (
+			name string
+			fc   domain.FacetCount
+		)
+		if err := rows.Scan(&name, &fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		facets[name] = append(facets[name], fc)
+	}
+
+	return facets, rows.Err()
 }
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type KYCRepository struct{}
+
+func NewKYCRepository() *KYCRepository {
+	return &KYCRepository{}
+}
+
+const kycSubmissionColumns = `id,user_id,level,legal_name,date_of_birth,COALESCE(business_name,''),COALESCE(registration_number,''),` +
+	`status,reviewed_by,reviewed_at,COALESCE(review_note,''),created_at,updated_at`
+
+func scanKYCSubmission(row rowScanner, k *domain.KYCSubmission) error {
+	return row.Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Level,
+		&k.LegalName,
+		&k.DateOfBirth,
+		&k.BusinessName,
+		&k.RegistrationNumber,
+		&k.Status,
+		&k.ReviewedBy,
+		&k.ReviewedAt,
+		&k.ReviewNote,
+		&k.CreatedAt,
+		&k.UpdatedAt,
+	)
+}
+
+const kycDocumentColumns = `id,submission_id,doc_type,file_name,blob_key,content_type,size_bytes,created_at`
+
+func scanKYCDocument(row rowScanner, d *domain.KYCDocument) error {
+	return row.Scan(
+		&d.ID,
+		&d.SubmissionID,
+		&d.DocType,
+		&d.FileName,
+		&d.Key,
+		&d.ContentType,
+		&d.SizeBytes,
+		&d.CreatedAt,
+	)
+}
+
+// Create stores a submission together with its documents.
+func (r *KYCRepository) Create(k *domain.KYCSubmission) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	k.CreatedAt = now
+	k.UpdatedAt = now
+
+	query := `
+		INSERT INTO kyc_submissions (user_id,level,legal_name,date_of_birth,business_name,registration_number,status,created_at,updated_at)
+		VALUES ($1,$2,$3,$4,NULLIF($5,''),NULLIF($6,''),$7,$8,$8)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query,
+		k.UserID,
+		k.Level,
+		k.LegalName,
+		k.DateOfBirth,
+		k.BusinessName,
+		k.RegistrationNumber,
+		k.Status,
+		now,
+	).Scan(&k.ID); err != nil {
+		return err
+	}
+
+	ins := `
+		INSERT INTO kyc_documents (submission_id,doc_type,file_name,blob_key,content_type,size_bytes,created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`
+	for i := range k.Documents {
+		d := &k.Documents[i]
+		d.SubmissionID = k.ID
+		d.CreatedAt = now
+		if err := tx.QueryRow(ctx, ins,
+			d.SubmissionID, d.DocType, d.FileName, d.Key, d.ContentType, d.SizeBytes, now,
+		).Scan(&d.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *KYCRepository) GetByID(id int64) (*domain.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE id=$1`
+
+	var k domain.KYCSubmission
+	if err := scanKYCSubmission(config.DB.QueryRow(context.Background(), query, id), &k); err != nil {
+		return nil, err
+	}
+
+	subs := []domain.KYCSubmission{k}
+	if err := r.attachDocuments(subs); err != nil {
+		return nil, err
+	}
+	return &subs[0], nil
+}
+
+// GetLatestByUser returns the user's most recent submission.
+func (r *KYCRepository) GetLatestByUser(userID int64) (*domain.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT 1`
+
+	var k domain.KYCSubmission
+	if err := scanKYCSubmission(config.DB.QueryRow(context.Background(), query, userID), &k); err != nil {
+		return nil, err
+	}
+
+	subs := []domain.KYCSubmission{k}
+	if err := r.attachDocuments(subs); err != nil {
+		return nil, err
+	}
+	return &subs[0], nil
+}
+
+// GetByStatus is the review queue, oldest first.
+func (r *KYCRepository) GetByStatus(status domain.KYCStatus) ([]domain.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE status=$1 ORDER BY created_at ASC, id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.KYCSubmission{}
+
+	for rows.Next() {
+		var k domain.KYCSubmission
+		if err := scanKYCSubmission(rows, &k); err != nil {
+			return nil, err
+		}
+		subs = append(subs, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDocuments(subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *KYCRepository) GetDocument(submissionID, documentID int64) (*domain.KYCDocument, error) {
+	query := `SELECT ` + kycDocumentColumns + ` FROM kyc_documents WHERE id=$1 AND submission_id=$2`
+
+	var d domain.KYCDocument
+	if err := scanKYCDocument(config.DB.QueryRow(context.Background(), query, documentID, submissionID), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetLevel returns the KYC level the user has reached.
+func (r *KYCRepository) GetLevel(userID int64) (domain.KYCLevel, error) {
+	var level domain.KYCLevel
+	err := config.DB.QueryRow(context.Background(), `SELECT kyc_level FROM users WHERE id=$1`, userID).Scan(&level)
+	return level, err
+}
+
+// Review records the decision on a PENDING submission. Approval raises the
+// user's KYC level in the same transaction; a level is never lowered. It
+// returns pgx.ErrNoRows when the submission is no longer pending.
+func (r *KYCRepository) Review(k *domain.KYCSubmission) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	k.UpdatedAt = time.Now()
+
+	up := `
+		UPDATE kyc_submissions
+		SET status=$1, reviewed_by=$2, reviewed_at=$3, review_note=NULLIF($4,''), updated_at=$5
+		WHERE id=$6 AND status='PENDING'
+	`
+	tag, err := tx.Exec(ctx, up, k.Status, k.ReviewedBy, k.ReviewedAt, k.ReviewNote, k.UpdatedAt, k.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if k.Status == domain.KYCApproved {
+		raise := `UPDATE users SET kyc_level = GREATEST(kyc_level, $1), updated_at=$2 WHERE id=$3`
+		if _, err := tx.Exec(ctx, raise, k.Level, k.UpdatedAt, k.UserID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// attachDocuments loads the documents of all given submissions in one query.
+func (r *KYCRepository) attachDocuments(subs []domain.KYCSubmission) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(subs))
+	index := make(map[int64]int, len(subs))
+	for i := range subs {
+		ids[i] = subs[i].ID
+		index[subs[i].ID] = i
+		subs[i].Documents = []domain.KYCDocument{}
+	}
+
+	query := `SELECT ` + kycDocumentColumns + ` FROM kyc_documents WHERE submission_id = ANY($1) ORDER BY id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d domain.KYCDocument
+		if err := scanKYCDocument(rows, &d); err != nil {
+			return err
+		}
+		i := index[d.SubmissionID]
+		subs[i].Documents = append(subs[i].Documents, d)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+type LoginAttemptRepository struct{}
+
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{}
+}
+
+func (r *LoginAttemptRepository) Create(a *domain.LoginAttempt) error {
+	a.CreatedAt = time.Now()
+	return config.DB.QueryRow(context.Background(), `
+		INSERT INTO login_attempts (user_id,email,ip,user_agent,result,created_at)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING id`,
+		a.UserID, a.Email, a.IP, a.UserAgent, a.Result, a.CreatedAt,
+	).Scan(&a.ID)
+}
+
+// ListByUser returns the user's most recent login attempts, newest first.
+func (r *LoginAttemptRepository) ListByUser(userID int64, limit int) ([]domain.LoginAttempt, error) {
+	rows, err := config.DB.Query(context.Background(), `
+		SELECT id, user_id, email, COALESCE(ip,''), COALESCE(user_agent,''), result, created_at
+		FROM login_attempts
+		WHERE user_id=$1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`, userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []domain.LoginAttempt{}
+	for rows.Next() {
+		var a domain.LoginAttempt
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Email, &a.IP, &a.UserAgent, &a.Result, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// FailuresByIP counts attempts from ip since the given time whose result is
+// one of failed and returns the time of the latest one.
+func (r *LoginAttemptRepository) FailuresByIP(ip string, since time.Time, failed []domain.LoginResult) (int, *time.Time, error) {
+	results := make([]string, len(failed))
+	for i, res := range failed {
+		results[i] = string(res)
+	}
+
+	var (
+		count int
+		last  *time.Time
+	)
+	err := config.DB.QueryRow(context.Background(), `
+		SELECT COUNT(*), MAX(created_at)
+		FROM login_attempts
+		WHERE ip=$1 AND created_at > $2 AND result = ANY($3)`,
+		ip, since, results,
+	).Scan(&count, &last)
+	return count, last, err
+}
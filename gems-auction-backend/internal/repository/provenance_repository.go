@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+type ProvenanceRepository struct{}
+
+func NewProvenanceRepository() *ProvenanceRepository {
+	return &ProvenanceRepository{}
+}
+
+func (r *ProvenanceRepository) Create(p *domain.ProvenanceEntry) error {
+	query := `
+		INSERT INTO gem_provenance (gem_id,event_type,party_name,location,description,auction_id,from_user_id,to_user_id,amount,occurred_at,recorded_by,created_at)
+		VALUES ($1,$2,NULLIF($3,''),NULLIF($4,''),NULLIF($5,''),$6,$7,$8,$9,$10,$11,$12)
+		RETURNING id
+	`
+
+	p.CreatedAt = time.Now()
+
+	return config.DB.QueryRow(context.Background(), query,
+		p.GemID,
+		p.EventType,
+		p.PartyName,
+		p.Location,
+		p.Description,
+		p.AuctionID,
+		p.FromUserID,
+		p.ToUserID,
+		p.Amount,
+		p.OccurredAt,
+		p.RecordedBy,
+		p.CreatedAt,
+	).Scan(&p.ID)
+}
+
+// GetByGem returns a gem's provenance in chronological order. Entries
+// without a known date sort by when they were recorded.
+func (r *ProvenanceRepository) GetByGem(gemID int64) ([]domain.ProvenanceEntry, error) {
+	query := `
+		SELECT id, gem_id, event_type, COALESCE(party_name,''), COALESCE(location,''), COALESCE(description,''),
+		       auction_id, from_user_id, to_user_id, amount, occurred_at, recorded_by, created_at
+		FROM gem_provenance
+		WHERE gem_id=$1
+		ORDER BY COALESCE(occurred_at, created_at) ASC, id ASC
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, gemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.ProvenanceEntry{}
+
+	for rows.Next() {
+		var p domain.ProvenanceEntry
+		err := rows.Scan(
+			&p.ID,
+			&p.GemID,
+			&p.EventType,
+			&p.PartyName,
+			&p.Location,
+			&p.Description,
+			&p.AuctionID,
+			&p.FromUserID,
+			&p.ToUserID,
+			&p.Amount,
+			&p.OccurredAt,
+			&p.RecordedBy,
+			&p.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, p)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type RefreshTokenRepository struct{}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{}
+}
+
+const refreshTokenColumns = `id,user_id,family_id,token_hash,expires_at,revoked_at,replaced_by,COALESCE(ip,''),COALESCE(user_agent,''),created_at`
+
+func scanRefreshToken(row rowScanner, t *domain.RefreshToken) error {
+	return row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.RevokedAt,
+		&t.ReplacedBy,
+		&t.IP,
+		&t.UserAgent,
+		&t.CreatedAt,
+	)
+}
+
+func (r *RefreshTokenRepository) Create(t *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id,family_id,token_hash,expires_at,ip,user_agent,created_at)
+		VALUES ($1,$2,$3,$4,NULLIF($5,''),NULLIF($6,''),$7)
+		RETURNING id
+	`
+
+	t.CreatedAt = time.Now()
+
+	return config.DB.QueryRow(context.Background(), query,
+		t.UserID,
+		t.FamilyID,
+		t.TokenHash,
+		t.ExpiresAt,
+		t.IP,
+		t.UserAgent,
+		t.CreatedAt,
+	).Scan(&t.ID)
+}
+
+func (r *RefreshTokenRepository) GetByHash(hash string) (*domain.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash=$1`
+
+	var t domain.RefreshToken
+	if err := scanRefreshToken(config.DB.QueryRow(context.Background(), query, hash), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Rotate revokes old and stores next in its place. It returns pgx.ErrNoRows
+// when old was already revoked, e.g. by a concurrent refresh.
+func (r *RefreshTokenRepository) Rotate(old *domain.RefreshToken, next *domain.RefreshToken) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	next.CreatedAt = now
+
+	ins := `
+		INSERT INTO refresh_tokens (user_id,family_id,token_hash,expires_at,ip,user_agent,created_at)
+		VALUES ($1,$2,$3,$4,NULLIF($5,''),NULLIF($6,''),$7)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, ins,
+		next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.IP, next.UserAgent, now,
+	).Scan(&next.ID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at=$1, replaced_by=$2 WHERE id=$3 AND revoked_at IS NULL`,
+		now, next.ID, old.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeFamily revokes every live token rotated from the same login.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE refresh_tokens SET revoked_at=$1 WHERE family_id=$2 AND revoked_at IS NULL`, time.Now(), familyID,
+	)
+	return err
+}
+
+// RevokeAllForUser revokes every live refresh token of the user.
+func (r *RefreshTokenRepository) RevokeAllForUser(userID int64) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE refresh_tokens SET revoked_at=$1 WHERE user_id=$2 AND revoked_at IS NULL`, time.Now(), userID,
+	)
+	return err
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type ReviewRepository struct{}
+
+func NewReviewRepository() *ReviewRepository {
+	return &ReviewRepository{}
+}
+
+const reviewColumns = `id,auction_id,reviewer_id,reviewee_id,reviewee_role,rating,comment,status,` +
+	`moderated_by,moderated_at,COALESCE(moderation_note,''),created_at,updated_at`
+
+func scanReview(row rowScanner, rv *domain.Review) error {
+	return row.Scan(
+		&rv.ID,
+		&rv.AuctionID,
+		&rv.ReviewerID,
+		&rv.RevieweeID,
+		&rv.RevieweeRole,
+		&rv.Rating,
+		&rv.Comment,
+		&rv.Status,
+		&rv.ModeratedBy,
+		&rv.ModeratedAt,
+		&rv.ModerationNote,
+		&rv.CreatedAt,
+		&rv.UpdatedAt,
+	)
+}
+
+const reviewDisputeColumns = `id,review_id,opened_by,reason,status,resolved_by,resolved_at,COALESCE(response,''),created_at,updated_at`
+
+func scanReviewDispute(row rowScanner, d *domain.ReviewDispute) error {
+	return row.Scan(
+		&d.ID,
+		&d.ReviewID,
+		&d.OpenedBy,
+		&d.Reason,
+		&d.Status,
+		&d.ResolvedBy,
+		&d.ResolvedAt,
+		&d.Response,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+}
+
+// AuctionSale is what decides who may review an auction: its seller, its
+// winner and whether the winner's payment has completed.
+type AuctionSale struct {
+	SellerID int64
+	WinnerID *int64
+	Status   domain.AuctionStatus
+	Paid     bool
+}
+
+func (r *ReviewRepository) GetAuctionSale(auctionID int64) (*AuctionSale, error) {
+	var s AuctionSale
+	err := config.DB.QueryRow(context.Background(), `
+		SELECT a.seller_id, a.winner_id, a.status,
+		       EXISTS (SELECT 1 FROM payments p WHERE p.auction_id = a.id AND p.user_id = a.winner_id AND p.status = 'COMPLETED')
+		FROM auctions a WHERE a.id=$1`, auctionID,
+	).Scan(&s.SellerID, &s.WinnerID, &s.Status, &s.Paid)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ReviewRepository) Create(rv *domain.Review) error {
+	query := `
+		INSERT INTO reviews (auction_id, reviewer_id, reviewee_id, reviewee_role, rating, comment, status, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$8)
+		RETURNING id
+	`
+	return config.DB.QueryRow(context.Background(), query,
+		rv.AuctionID, rv.ReviewerID, rv.RevieweeID, rv.RevieweeRole, rv.Rating, rv.Comment, rv.Status, rv.CreatedAt,
+	).Scan(&rv.ID)
+}
+
+func (r *ReviewRepository) GetByID(id int64) (*domain.Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE id=$1`
+
+	var rv domain.Review
+	if err := scanReview(config.DB.QueryRow(context.Background(), query, id), &rv); err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// ReviewFilter narrows Search. Zero values match everything.
+type ReviewFilter struct {
+	AuctionID    int64
+	RevieweeID   int64
+	RevieweeRole domain.ReviewRole
+	Status       domain.ReviewStatus
+	Limit        int
+	Offset       int
+}
+
+// Search lists reviews matching the filter, newest first, and the total
+// number of matches.
+func (r *ReviewRepository) Search(f ReviewFilter) ([]domain.Review, int64, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.AuctionID != 0 {
+		where = append(where, "auction_id = "+arg(f.AuctionID))
+	}
+	if f.RevieweeID != 0 {
+		where = append(where, "reviewee_id = "+arg(f.RevieweeID))
+	}
+	if f.RevieweeRole != "" {
+		where = append(where, "reviewee_role = "+arg(f.RevieweeRole))
+	}
+	if f.Status != "" {
+		where = append(where, "status = "+arg(f.Status))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	ctx := context.Background()
+
+	var total int64
+	if err := config.DB.QueryRow(ctx, "SELECT COUNT(*) FROM reviews"+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + reviewColumns + ` FROM reviews` + whereSQL +
+		" ORDER BY created_at DESC, id DESC LIMIT " + arg(f.Limit) + " OFFSET " + arg(f.Offset)
+
+	rows, err := config.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []domain.Review{}
+	for rows.Next() {
+		var rv domain.Review
+		if err := scanReview(rows, &rv); err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, total, rows.Err()
+}
+
+// GetRatings aggregates the visible reviews a user received as a seller and
+// as a buyer.
+func (r *ReviewRepository) GetRatings(userID int64) (seller, buyer domain.RatingSummary, err error) {
+	err = config.DB.QueryRow(context.Background(), `
+		SELECT
+			COALESCE(AVG(rating) FILTER (WHERE reviewee_role = 'SELLER'), 0)::float8,
+			COUNT(*) FILTER (WHERE reviewee_role = 'SELLER'),
+			COALESCE(AVG(rating) FILTER (WHERE reviewee_role = 'BUYER'), 0)::float8,
+			COUNT(*) FILTER (WHERE reviewee_role = 'BUYER')
+		FROM reviews WHERE reviewee_id=$1 AND status='VISIBLE'`, userID,
+	).Scan(&seller.Average, &seller.Count, &buyer.Average, &buyer.Count)
+	return seller, buyer, err
+}
+
+// Moderate records an admin's decision to hide or restore a review. It
+// returns pgx.ErrNoRows for unknown reviews.
+func (r *ReviewRepository) Moderate(rv *domain.Review) error {
+	rv.UpdatedAt = time.Now()
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE reviews SET status=$1, moderated_by=$2, moderated_at=$3, moderation_note=NULLIF($4,''), updated_at=$5 WHERE id=$6`,
+		rv.Status, rv.ModeratedBy, rv.ModeratedAt, rv.ModerationNote, rv.UpdatedAt, rv.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ReviewRepository) CreateDispute(d *domain.ReviewDispute) error {
+	query := `
+		INSERT INTO review_disputes (review_id, opened_by, reason, status, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$5)
+		RETURNING id
+	`
+	return config.DB.QueryRow(context.Background(), query,
+		d.ReviewID, d.OpenedBy, d.Reason, d.Status, d.CreatedAt,
+	).Scan(&d.ID)
+}
+
+func (r *ReviewRepository) GetDispute(id int64) (*domain.ReviewDispute, error) {
+	query := `SELECT ` + reviewDisputeColumns + ` FROM review_disputes WHERE id=$1`
+
+	var d domain.ReviewDispute
+	if err := scanReviewDispute(config.DB.QueryRow(context.Background(), query, id), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetDisputesByStatus lists disputes oldest first, so the queue is worked in
+// arrival order.
+func (r *ReviewRepository) GetDisputesByStatus(status domain.DisputeStatus) ([]domain.ReviewDispute, error) {
+	query := `SELECT ` + reviewDisputeColumns + ` FROM review_disputes WHERE status=$1 ORDER BY created_at ASC, id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes := []domain.ReviewDispute{}
+	for rows.Next() {
+		var d domain.ReviewDispute
+		if err := scanReviewDispute(rows, &d); err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, d)
+	}
+	return disputes, rows.Err()
+}
+
+// ResolveDispute answers an OPEN dispute. Upholding it hides the review in
+// the same transaction. It returns pgx.ErrNoRows when the dispute is no
+// longer open.
+func (r *ReviewRepository) ResolveDispute(d *domain.ReviewDispute) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	d.UpdatedAt = time.Now()
+
+	up := `
+		UPDATE review_disputes
+		SET status=$1, resolved_by=$2, resolved_at=$3, response=$4, updated_at=$5
+		WHERE id=$6 AND status='OPEN'
+	`
+	tag, err := tx.Exec(ctx, up, d.Status, d.ResolvedBy, d.ResolvedAt, d.Response, d.UpdatedAt, d.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if d.Status == domain.DisputeUpheld {
+		hide := `
+			UPDATE reviews SET status='HIDDEN', moderated_by=$1, moderated_at=$2, moderation_note=$3, updated_at=$2
+			WHERE id=$4
+		`
+		if _, err := tx.Exec(ctx, hide, d.ResolvedBy, d.UpdatedAt, d.Response, d.ReviewID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type RoleRepository struct{}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{}
+}
+
+// List returns every role with the permissions it grants.
+func (r *RoleRepository) List() ([]domain.Role, error) {
+	query := `
+		SELECT r.name, r.description, r.require_2fa,
+		       COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name, r.description, r.require_2fa
+		ORDER BY r.name
+	`
+
+	rows, err := config.DB.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []domain.Role{}
+
+	for rows.Next() {
+		var (
+			role  domain.Role
+			perms []string
+		)
+		if err := rows.Scan(&role.Name, &role.Description, &role.Require2FA, &perms); err != nil {
+			return nil, err
+		}
+		role.Permissions = make([]domain.Permission, len(perms))
+		for i, p := range perms {
+			role.Permissions[i] = domain.Permission(p)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetUserAccess returns the roles held by a user and the union of the
+// permissions those roles grant.
+func (r *RoleRepository) GetUserAccess(userID int64) ([]domain.UserRole, []domain.Permission, error) {
+	ctx := context.Background()
+
+	var roleNames, permNames []string
+
+	rq := `SELECT COALESCE(array_agg(role ORDER BY role), '{}') FROM user_roles WHERE user_id=$1`
+	if err := config.DB.QueryRow(ctx, rq, userID).Scan(&roleNames); err != nil {
+		return nil, nil, err
+	}
+
+	pq := `
+		SELECT COALESCE(array_agg(DISTINCT rp.permission ORDER BY rp.permission), '{}')
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.user_id=$1
+	`
+	if err := config.DB.QueryRow(ctx, pq, userID).Scan(&permNames); err != nil {
+		return nil, nil, err
+	}
+
+	roles := make([]domain.UserRole, len(roleNames))
+	for i, n := range roleNames {
+		roles[i] = domain.UserRole(n)
+	}
+	perms := make([]domain.Permission, len(permNames))
+	for i, n := range permNames {
+		perms[i] = domain.Permission(n)
+	}
+
+	return roles, perms, nil
+}
+
+// SetUserRoles replaces the roles held by a user and keeps users.role in
+// step with the new primary role. The token version is bumped so access
+// tokens carrying the old roles stop working.
+func (r *RoleRepository) SetUserRoles(userID, grantedBy int64, roles []domain.UserRole) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+
+	now := time.Now()
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET role=$1, token_version = token_version + 1, updated_at=$2 WHERE id=$3`, domain.PrimaryRole(roles), now, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_roles WHERE user_id=$1 AND NOT (role = ANY($2))`, userID, names); err != nil {
+		return err
+	}
+
+	ins := `INSERT INTO user_roles (user_id, role, granted_by, granted_at)
+	        SELECT $1, unnest($2::varchar[]), $3, $4
+	        ON CONFLICT (user_id, role) DO NOTHING`
+	if _, err := tx.Exec(ctx, ins, userID, names, grantedBy, now); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetRequire2FA turns the two-factor requirement of a role on or off.
+func (r *RoleRepository) SetRequire2FA(role domain.UserRole, required bool) error {
+	tag, err := config.DB.Exec(context.Background(), `UPDATE roles SET require_2fa=$1 WHERE name=$2`, required, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RequiresTwoFactor reports whether any role held by the user requires 2FA.
+func (r *RoleRepository) RequiresTwoFactor(userID int64) (bool, error) {
+	var required bool
+	err := config.DB.QueryRow(context.Background(), `
+		SELECT EXISTS (
+			SELECT 1 FROM user_roles ur JOIN roles r ON r.name = ur.role
+			WHERE ur.user_id=$1 AND r.require_2fa
+		)`, userID,
+	).Scan(&required)
+	return required, err
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type SellerApplicationRepository struct{}
+
+func NewSellerApplicationRepository() *SellerApplicationRepository {
+	return &SellerApplicationRepository{}
+}
+
+const sellerApplicationColumns = `id,user_id,business_name,business_type,COALESCE(registration_number,''),COALESCE(tax_id,''),` +
+	`phone,address,COALESCE(website,''),status,reviewed_by,reviewed_at,COALESCE(review_note,''),created_at,updated_at`
+
+func scanSellerApplication(row rowScanner, a *domain.SellerApplication) error {
+	return row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.BusinessName,
+		&a.BusinessType,
+		&a.RegistrationNumber,
+		&a.TaxID,
+		&a.Phone,
+		&a.Address,
+		&a.Website,
+		&a.Status,
+		&a.ReviewedBy,
+		&a.ReviewedAt,
+		&a.ReviewNote,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+}
+
+const sellerDocumentColumns = `id,application_id,doc_type,file_name,blob_key,content_type,size_bytes,created_at`
+
+func scanSellerDocument(row rowScanner, d *domain.SellerDocument) error {
+	return row.Scan(
+		&d.ID,
+		&d.ApplicationID,
+		&d.DocType,
+		&d.FileName,
+		&d.Key,
+		&d.ContentType,
+		&d.SizeBytes,
+		&d.CreatedAt,
+	)
+}
+
+// Create stores an application together with its documents.
+func (r *SellerApplicationRepository) Create(a *domain.SellerApplication) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	query := `
+		INSERT INTO seller_applications (user_id,business_name,business_type,registration_number,tax_id,phone,address,website,status,created_at,updated_at)
+		VALUES ($1,$2,$3,NULLIF($4,''),NULLIF($5,''),$6,$7,NULLIF($8,''),$9,$10,$10)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query,
+		a.UserID,
+		a.BusinessName,
+		a.BusinessType,
+		a.RegistrationNumber,
+		a.TaxID,
+		a.Phone,
+		a.Address,
+		a.Website,
+		a.Status,
+		now,
+	).Scan(&a.ID); err != nil {
+		return err
+	}
+
+	ins := `
+		INSERT INTO seller_application_documents (application_id,doc_type,file_name,blob_key,content_type,size_bytes,created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`
+	for i := range a.Documents {
+		d := &a.Documents[i]
+		d.ApplicationID = a.ID
+		d.CreatedAt = now
+		if err := tx.QueryRow(ctx, ins,
+			d.ApplicationID, d.DocType, d.FileName, d.Key, d.ContentType, d.SizeBytes, now,
+		).Scan(&d.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *SellerApplicationRepository) GetByID(id int64) (*domain.SellerApplication, error) {
+	query := `SELECT ` + sellerApplicationColumns + ` FROM seller_applications WHERE id=$1`
+
+	var a domain.SellerApplication
+	if err := scanSellerApplication(config.DB.QueryRow(context.Background(), query, id), &a); err != nil {
+		return nil, err
+	}
+
+	apps := []domain.SellerApplication{a}
+	if err := r.attachDocuments(apps); err != nil {
+		return nil, err
+	}
+	return &apps[0], nil
+}
+
+// GetLatestByUser returns the user's most recent application.
+func (r *SellerApplicationRepository) GetLatestByUser(userID int64) (*domain.SellerApplication, error) {
+	query := `SELECT ` + sellerApplicationColumns + ` FROM seller_applications WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT 1`
+
+	var a domain.SellerApplication
+	if err := scanSellerApplication(config.DB.QueryRow(context.Background(), query, userID), &a); err != nil {
+		return nil, err
+	}
+
+	apps := []domain.SellerApplication{a}
+	if err := r.attachDocuments(apps); err != nil {
+		return nil, err
+	}
+	return &apps[0], nil
+}
+
+// GetByStatus is the review queue, oldest first.
+func (r *SellerApplicationRepository) GetByStatus(status domain.SellerApplicationStatus) ([]domain.SellerApplication, error) {
+	query := `SELECT ` + sellerApplicationColumns + ` FROM seller_applications WHERE status=$1 ORDER BY created_at ASC, id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apps := []domain.SellerApplication{}
+
+	for rows.Next() {
+		var a domain.SellerApplication
+		if err := scanSellerApplication(rows, &a); err != nil {
+			return nil, err
+		}
+		apps = append(apps, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDocuments(apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+func (r *SellerApplicationRepository) GetDocument(applicationID, documentID int64) (*domain.SellerDocument, error) {
+	query := `SELECT ` + sellerDocumentColumns + ` FROM seller_application_documents WHERE id=$1 AND application_id=$2`
+
+	var d domain.SellerDocument
+	if err := scanSellerDocument(config.DB.QueryRow(context.Background(), query, documentID, applicationID), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Review records the decision on a PENDING application. Approval grants the
+// SELLER role in the same transaction. It returns pgx.ErrNoRows when the
+// application is no longer pending.
+func (r *SellerApplicationRepository) Review(a *domain.SellerApplication) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	a.UpdatedAt = time.Now()
+
+	up := `
+		UPDATE seller_applications
+		SET status=$1, reviewed_by=$2, reviewed_at=$3, review_note=NULLIF($4,''), updated_at=$5
+		WHERE id=$6 AND status='PENDING'
+	`
+	tag, err := tx.Exec(ctx, up, a.Status, a.ReviewedBy, a.ReviewedAt, a.ReviewNote, a.UpdatedAt, a.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if a.Status == domain.SellerApplicationApproved {
+		grant := `INSERT INTO user_roles (user_id, role, granted_by, granted_at)
+		          VALUES ($1,$2,$3,$4)
+		          ON CONFLICT (user_id, role) DO NOTHING`
+		if _, err := tx.Exec(ctx, grant, a.UserID, domain.RoleSeller, a.ReviewedBy, a.UpdatedAt); err != nil {
+			return err
+		}
+
+		// bumping the token version makes the applicant refresh into a
+		// token that carries the new role
+		primary := `UPDATE users
+		            SET role = CASE WHEN role=$1 THEN $2 ELSE role END,
+		                token_version = token_version + 1, updated_at=$3
+		            WHERE id=$4`
+		if _, err := tx.Exec(ctx, primary, domain.RoleBuyer, domain.RoleSeller, a.UpdatedAt, a.UserID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// attachDocuments loads the documents of all given applications in one query.
+func (r *SellerApplicationRepository) attachDocuments(apps []domain.SellerApplication) error {
+	if len(apps) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(apps))
+	index := make(map[int64]int, len(apps))
+	for i := range apps {
+		ids[i] = apps[i].ID
+		index[apps[i].ID] = i
+		apps[i].Documents = []domain.SellerDocument{}
+	}
+
+	query := `SELECT ` + sellerDocumentColumns + ` FROM seller_application_documents WHERE application_id = ANY($1) ORDER BY id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d domain.SellerDocument
+		if err := scanSellerDocument(rows, &d); err != nil {
+			return err
+		}
+		i := index[d.ApplicationID]
+		apps[i].Documents = append(apps[i].Documents, d)
+	}
+
+	return rows.Err()
+}
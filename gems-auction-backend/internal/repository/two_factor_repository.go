@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type TwoFactorRepository struct{}
+
+func NewTwoFactorRepository() *TwoFactorRepository {
+	return &TwoFactorRepository{}
+}
+
+func (r *TwoFactorRepository) GetState(userID int64) (*domain.TwoFactorState, error) {
+	var st domain.TwoFactorState
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(totp_secret_enc,''), totp_enabled_at, totp_last_step FROM users WHERE id=$1`, userID,
+	).Scan(&st.SecretEnc, &st.EnabledAt, &st.LastStep)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SetPendingSecret stores a new secret for a user who has not enabled 2FA
+// yet. It returns pgx.ErrNoRows when 2FA is already enabled.
+func (r *TwoFactorRepository) SetPendingSecret(userID int64, secretEnc string) error {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET totp_secret_enc=$1, totp_last_step=NULL, updated_at=$2 WHERE id=$3 AND totp_enabled_at IS NULL`,
+		secretEnc, time.Now(), userID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Enable switches 2FA on after the first valid code and stores a fresh set
+// of recovery codes.
+func (r *TwoFactorRepository) Enable(userID, step int64, codeHashes []string) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx,
+		`UPDATE users SET totp_enabled_at=$1, totp_last_step=$2, updated_at=$1
+		 WHERE id=$3 AND totp_enabled_at IS NULL AND totp_secret_enc IS NOT NULL`,
+		now, step, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, now); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Disable removes the secret and all recovery codes.
+func (r *TwoFactorRepository) Disable(userID int64) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET totp_secret_enc=NULL, totp_enabled_at=NULL, totp_last_step=NULL, updated_at=$1 WHERE id=$2`,
+		time.Now(), userID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep records step as the last accepted TOTP step. It returns false when
+// that step (or a later one) was already used.
+func (r *TwoFactorRepository) UseStep(userID, step int64) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET totp_last_step=$1 WHERE id=$2 AND (totp_last_step IS NULL OR totp_last_step < $1)`,
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode burns an unused recovery code. It returns false when the
+// code is unknown or already used.
+func (r *TwoFactorRepository) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE user_recovery_codes SET used_at=$1 WHERE user_id=$2 AND code_hash=$3 AND used_at IS NULL`,
+		time.Now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(userID int64) (int, error) {
+	var n int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id=$1 AND used_at IS NULL`, userID,
+	).Scan(&n)
+	return n, err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64, codeHashes []string, at time.Time) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+
+	ins := `INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1,$2,$3)`
+	for _, h := range codeHashes {
+		if _, err := tx.Exec(ctx, ins, userID, h, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserRepository struct{}
@@ -14,16 +17,31 @@ func NewUserRepository() *UserRepository {
 	return &UserRepository{}
 }
 
-// Create User
+// Create User. user.Roles (or user.Role when empty) are granted in
+// user_roles.
 func (r *UserRepository) Create(user *domain.User) error {
 	query := `
-		INSERT INTO users (full_name, email, password, role, is_active, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7)
-		RETURNING id
+		WITH u AS (
+			INSERT INTO users (full_name, email, password, role, is_active, created_at, updated_at)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)
+			RETURNING id
+		), ur AS (
+			INSERT INTO user_roles (user_id, role, granted_at)
+			SELECT id, unnest($8::varchar[]), $6 FROM u
+		)
+		SELECT id FROM u
 	`
 
 	now := time.Now()
 
+	roles := []string{string(user.Role)}
+	if len(user.Roles) > 0 {
+		roles = make([]string, len(user.Roles))
+		for i, r := range user.Roles {
+			roles[i] = string(r)
+		}
+	}
+
 	return config.DB.QueryRow(context.Background(), query,
 		user.FullName,
 		user.Email,
@@ -32,32 +50,301 @@ func (r *UserRepository) Create(user *domain.User) error {
 		true,
 		now,
 		now,
+		roles,
 	).Scan(&user.ID)
 }
 
-// Get by Email
-func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
-	query := `
-		SELECT id, full_name, email, password, role, is_active, created_at, updated_at
-		FROM users WHERE email=$1
-	`
+const userColumns = `id, full_name, email, password, role, is_active, email_verified_at, token_version, locked_until, failed_login_count,
+		COALESCE(phone,''), COALESCE(tax_id,''), kyc_level, password_reset_required, created_at, updated_at`
 
-	var user domain.User
-
-	err := config.DB.QueryRow(context.Background(), query, email).Scan(
+// scanUser scans userColumns, followed by any extra destinations.
+func scanUser(row rowScanner, user *domain.User, extra ...any) error {
+	dest := []any{
 		&user.ID,
 		&user.FullName,
 		&user.Email,
 		&user.Password,
 		&user.Role,
 		&user.IsActive,
+		&user.EmailVerifiedAt,
+		&user.TokenVersion,
+		&user.LockedUntil,
+		&user.FailedLoginCount,
+		&user.Phone,
+		&user.TaxID,
+		&user.KYCLevel,
+		&user.PasswordResetRequired,
 		&user.CreatedAt,
 		&user.UpdatedAt,
-	)
+	}
+	return row.Scan(append(dest, extra...)...)
+}
 
-	if err != nil {
+// Get by Email
+func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email=$1`
+
+	var user domain.User
+	if err := scanUser(config.DB.QueryRow(context.Background(), query, email), &user); err != nil {
 		return nil, err
 	}
+	return &user, nil
+}
+
+// Get by ID
+func (r *UserRepository) GetByID(id int64) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id=$1`
 
+	var user domain.User
+	if err := scanUser(config.DB.QueryRow(context.Background(), query, id), &user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
+
+// GetAuthState returns what AuthMiddleware needs to accept a token.
+func (r *UserRepository) GetAuthState(id int64) (isActive bool, tokenVersion int, err error) {
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(is_active, FALSE), token_version FROM users WHERE id=$1`, id,
+	).Scan(&isActive, &tokenVersion)
+	return isActive, tokenVersion, err
+}
+
+// BumpTokenVersion revokes every access token issued to the user so far.
+func (r *UserRepository) BumpTokenVersion(id int64) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET token_version = token_version + 1, updated_at=$1 WHERE id=$2`, time.Now(), id,
+	)
+	return err
+}
+
+// MarkEmailVerified records that the user confirmed their address. It keeps
+// the first verification time.
+func (r *UserRepository) MarkEmailVerified(id int64) error {
+	now := time.Now()
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET email_verified_at = COALESCE(email_verified_at, $1), updated_at=$1 WHERE id=$2`, now, id,
+	)
+	return err
+}
+
+// UpdatePassword stores a new password hash, clears a forced reset and
+// revokes outstanding access tokens.
+func (r *UserRepository) UpdatePassword(id int64, hashed string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET password=$1, password_reset_required=FALSE, token_version = token_version + 1, updated_at=$2 WHERE id=$3`,
+		hashed, time.Now(), id,
+	)
+	return err
+}
+
+// IncrementFailedLogins records a failed login and returns the number of
+// consecutive failures.
+func (r *UserRepository) IncrementFailedLogins(id int64) (int, error) {
+	var count int
+	err := config.DB.QueryRow(context.Background(),
+		`UPDATE users SET failed_login_count = failed_login_count + 1 WHERE id=$1 RETURNING failed_login_count`, id,
+	).Scan(&count)
+	return count, err
+}
+
+// LockUntil locks the account against logins until the given time.
+func (r *UserRepository) LockUntil(id int64, until time.Time) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET locked_until=$1, updated_at=$2 WHERE id=$3`, until, time.Now(), id,
+	)
+	return err
+}
+
+// ClearLoginFailures resets the failure count and lifts any lock. It
+// returns pgx.ErrNoRows for unknown users.
+func (r *UserRepository) ClearLoginFailures(id int64) error {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET failed_login_count=0, locked_until=NULL WHERE id=$1`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateProfile stores the user's editable profile fields.
+func (r *UserRepository) UpdateProfile(user *domain.User) error {
+	user.UpdatedAt = time.Now()
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET full_name=$1, phone=NULLIF($2,''), tax_id=NULLIF($3,''), updated_at=$4 WHERE id=$5`,
+		user.FullName, user.Phone, user.TaxID, user.UpdatedAt, user.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UserFilter narrows Search. Zero values match everything.
+type UserFilter struct {
+	Query  string
+	Role   domain.UserRole
+	Active *bool
+	Limit  int
+	Offset int
+}
+
+// Search lists users matching the filter, newest first, with their roles,
+// and the total number of matches.
+func (r *UserRepository) Search(f UserFilter) ([]domain.User, int64, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.Query != "" {
+		pattern := arg("%" + likeEscaper.Replace(f.Query) + "%")
+		where = append(where, "(email ILIKE "+pattern+" OR full_name ILIKE "+pattern+")")
+	}
+	if f.Role != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = users.id AND ur.role = "+arg(f.Role)+")")
+	}
+	if f.Active != nil {
+		where = append(where, "is_active = "+arg(*f.Active))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	ctx := context.Background()
+
+	var total int64
+	if err := config.DB.QueryRow(ctx, "SELECT COUNT(*) FROM users"+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + userColumns + `,
+		       (SELECT COALESCE(array_agg(ur.role ORDER BY ur.role), '{}') FROM user_roles ur WHERE ur.user_id = users.id)
+		FROM users` + whereSQL +
+		" ORDER BY created_at DESC, id DESC LIMIT " + arg(f.Limit) + " OFFSET " + arg(f.Offset)
+
+	rows, err := config.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		var (
+			u     domain.User
+			roles []string
+		)
+		if err := scanUser(rows, &u, &roles); err != nil {
+			return nil, 0, err
+		}
+		u.Roles = make([]domain.UserRole, len(roles))
+		for i, role := range roles {
+			u.Roles[i] = domain.UserRole(role)
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+// likeEscaper escapes LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SetActive enables or disables a user. Either way outstanding access
+// tokens are revoked; disabling also revokes refresh tokens. It returns
+// pgx.ErrNoRows for unknown users.
+func (r *UserRepository) SetActive(id int64, active bool) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx,
+		`UPDATE users SET is_active=$1, token_version = token_version + 1, updated_at=$2 WHERE id=$3`, active, now, id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if !active {
+		if _, err := tx.Exec(ctx,
+			`UPDATE refresh_tokens SET revoked_at=$1 WHERE user_id=$2 AND revoked_at IS NULL`, now, id,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RequirePasswordReset stops the current password from working until the
+// user sets a new one, and revokes outstanding access tokens.
+func (r *UserRepository) RequirePasswordReset(id int64) error {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET password_reset_required=TRUE, token_version = token_version + 1, updated_at=$1 WHERE id=$2`, time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetSummary aggregates a user's activity across bids, auctions, gems and
+// payments.
+func (r *UserRepository) GetSummary(id int64) (*domain.UserSummary, error) {
+	s := domain.UserSummary{UserID: id}
+	err := config.DB.QueryRow(context.Background(), `
+		SELECT
+			(SELECT COUNT(*) FROM bids WHERE user_id=$1),
+			(SELECT COUNT(DISTINCT auction_id) FROM bids WHERE user_id=$1),
+			(SELECT MAX(created_at) FROM bids WHERE user_id=$1),
+			(SELECT COUNT(*) FROM auctions WHERE winner_id=$1 AND status='ENDED'),
+			(SELECT COALESCE(SUM(current_price),0) FROM auctions WHERE winner_id=$1 AND status='ENDED'),
+			(SELECT COUNT(*) FROM auctions WHERE seller_id=$1),
+			(SELECT COUNT(*) FROM auctions WHERE seller_id=$1 AND status IN ('SCHEDULED','LIVE')),
+			(SELECT COUNT(*) FROM auctions WHERE seller_id=$1 AND status='ENDED' AND winner_id IS NOT NULL),
+			(SELECT COUNT(*) FROM gems WHERE seller_id=$1),
+			(SELECT COUNT(*) FROM payments WHERE user_id=$1),
+			(SELECT COUNT(*) FROM payments WHERE user_id=$1 AND status='PENDING'),
+			(SELECT COALESCE(SUM(amount),0) FROM payments WHERE user_id=$1 AND status='COMPLETED')`, id,
+	).Scan(
+		&s.BidCount,
+		&s.AuctionsBidOn,
+		&s.LastBidAt,
+		&s.WinCount,
+		&s.WonValue,
+		&s.ListingCount,
+		&s.OpenListings,
+		&s.SoldListings,
+		&s.GemsOwned,
+		&s.PaymentCount,
+		&s.PendingPayments,
+		&s.AmountPaid,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+type UserTokenRepository struct{}
+
+func NewUserTokenRepository() *UserTokenRepository {
+	return &UserTokenRepository{}
+}
+
+// Create stores a new token and invalidates the user's earlier unused tokens
+// for the same purpose, so only the latest emailed link works.
+func (r *UserTokenRepository) Create(t *domain.UserToken) error {
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	t.CreatedAt = time.Now()
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE user_tokens SET used_at=$1 WHERE user_id=$2 AND purpose=$3 AND used_at IS NULL`,
+		t.CreatedAt, t.UserID, t.Purpose,
+	); err != nil {
+		return err
+	}
+
+	ins := `
+		INSERT INTO user_tokens (user_id,purpose,token_hash,expires_at,created_at)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, ins, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt, t.CreatedAt).Scan(&t.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LastCreatedAt returns when the user was last sent a token for purpose, or
+// nil if never.
+func (r *UserTokenRepository) LastCreatedAt(userID int64, purpose domain.UserTokenPurpose) (*time.Time, error) {
+	var at *time.Time
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT MAX(created_at) FROM user_tokens WHERE user_id=$1 AND purpose=$2`, userID, purpose,
+	).Scan(&at)
+	return at, err
+}
+
+// Consume marks an unused, unexpired token as used and returns it. It
+// returns pgx.ErrNoRows for unknown, expired or already used tokens.
+func (r *UserTokenRepository) Consume(hash string, purpose domain.UserTokenPurpose) (*domain.UserToken, error) {
+	query := `
+		UPDATE user_tokens SET used_at=$1
+		WHERE token_hash=$2 AND purpose=$3 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+	`
+
+	var t domain.UserToken
+	err := config.DB.QueryRow(context.Background(), query, time.Now(), hash, purpose).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Purpose,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
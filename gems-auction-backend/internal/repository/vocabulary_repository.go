@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+type VocabularyRepository struct{}
+
+func NewVocabularyRepository() *VocabularyRepository {
+	return &VocabularyRepository{}
+}
+
+const vocabularyColumns = `id,kind,code,label,COALESCE(parent_code,''),sort_order,is_active,created_at,updated_at`
+
+func scanVocabularyTerm(row rowScanner, t *domain.VocabularyTerm) error {
+	return row.Scan(
+		&t.ID,
+		&t.Kind,
+		&t.Code,
+		&t.Label,
+		&t.ParentCode,
+		&t.SortOrder,
+		&t.IsActive,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+}
+
+func (r *VocabularyRepository) Create(t *domain.VocabularyTerm) error {
+	query := `
+		INSERT INTO gem_vocabularies (kind,code,label,parent_code,sort_order,is_active,created_at,updated_at)
+		VALUES ($1,$2,$3,NULLIF($4,''),$5,$6,$7,$8)
+		RETURNING id
+	`
+
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	return config.DB.QueryRow(context.Background(), query,
+		t.Kind,
+		t.Code,
+		t.Label,
+		t.ParentCode,
+		t.SortOrder,
+		t.IsActive,
+		now,
+		now,
+	).Scan(&t.ID)
+}
+
+func (r *VocabularyRepository) Update(t *domain.VocabularyTerm) error {
+	query := `
+		UPDATE gem_vocabularies
+		SET label=$1, parent_code=NULLIF($2,''), sort_order=$3, is_active=$4, updated_at=$5
+		WHERE id=$6
+	`
+
+	t.UpdatedAt = time.Now()
+
+	_, err := config.DB.Exec(context.Background(), query,
+		t.Label,
+		t.ParentCode,
+		t.SortOrder,
+		t.IsActive,
+		t.UpdatedAt,
+		t.ID,
+	)
+	return err
+}
+
+func (r *VocabularyRepository) GetByID(id int64) (*domain.VocabularyTerm, error) {
+	query := `SELECT ` + vocabularyColumns + ` FROM gem_vocabularies WHERE id=$1`
+
+	var t domain.VocabularyTerm
+	if err := scanVocabularyTerm(config.DB.QueryRow(context.Background(), query, id), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetByCode looks up a term by kind and code.
+func (r *VocabularyRepository) GetByCode(kind domain.VocabularyKind, code string) (*domain.VocabularyTerm, error) {
+	query := `SELECT ` + vocabularyColumns + ` FROM gem_vocabularies WHERE kind=$1 AND code=$2`
+
+	var t domain.VocabularyTerm
+	if err := scanVocabularyTerm(config.DB.QueryRow(context.Background(), query, kind, code), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns the terms of one kind (or all kinds when kind is empty).
+func (r *VocabularyRepository) List(kind domain.VocabularyKind, includeInactive bool) ([]domain.VocabularyTerm, error) {
+	query := `
+		SELECT ` + vocabularyColumns + `
+		FROM gem_vocabularies
+		WHERE ($1 = '' OR kind = $1) AND ($2 OR is_active)
+		ORDER BY kind ASC, sort_order ASC, label ASC
+	`
+
+	rows, err := config.DB.Query(context.Background(), query, string(kind), includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []domain.VocabularyTerm{}
+
+	for rows.Next() {
+		var t domain.VocabularyTerm
+		if err := scanVocabularyTerm(rows, &t); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, rows.Err()
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+)
+
+var ErrAdminAccount = errors.New("only admins can manage admin accounts")
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+// SessionDisconnector closes a user's live connections. The websocket
+// Manager implements it.
+type SessionDisconnector interface {
+	DisconnectUser(userID int64)
+}
+
+type AdminUserService struct {
+	userRepo *repository.UserRepository
+	roleRepo *repository.RoleRepository
+	auth     *AuthService
+	sessions SessionDisconnector // can be nil
+}
+
+func NewAdminUserService(
+	userRepo *repository.UserRepository,
+	roleRepo *repository.RoleRepository,
+	auth *AuthService,
+	sessions SessionDisconnector,
+) *AdminUserService {
+	return &AdminUserService{userRepo: userRepo, roleRepo: roleRepo, auth: auth, sessions: sessions}
+}
+
+type SearchUsersRequest struct {
+	Query  string `form:"q"`
+	Role   string `form:"role"`
+	Active *bool  `form:"active"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+type UserPage struct {
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+	Items  []domain.User `json:"items"`
+}
+
+// Search lists users by email or name, optionally filtered by role and
+// active flag.
+func (s *AdminUserService) Search(req SearchUsersRequest) (*UserPage, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultUserPageSize
+	}
+	if limit > maxUserPageSize {
+		limit = maxUserPageSize
+	}
+	if req.Offset < 0 {
+		return nil, errors.New("offset must be >= 0")
+	}
+
+	var role domain.UserRole
+	if req.Role != "" {
+		roles, err := normalizeRoles([]domain.UserRole{domain.UserRole(req.Role)})
+		if err != nil {
+			return nil, err
+		}
+		if len(roles) == 1 {
+			role = roles[0]
+		}
+	}
+
+	users, total, err := s.userRepo.Search(repository.UserFilter{
+		Query:  strings.TrimSpace(req.Query),
+		Role:   role,
+		Active: req.Active,
+		Limit:  limit,
+		Offset: req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserPage{Total: total, Limit: limit, Offset: req.Offset, Items: users}, nil
+}
+
+// GetUser returns a user with their roles and permissions.
+func (s *AdminUserService) GetUser(userID int64) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Roles, user.Permissions, err = s.roleRepo.GetUserAccess(userID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Summary returns a user's bids, wins, listings and payments.
+func (s *AdminUserService) Summary(userID int64) (*domain.UserSummary, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetSummary(userID)
+}
+
+// SetActive activates or deactivates a user. Deactivation ends all of the
+// user's sessions, including open websocket connections.
+func (s *AdminUserService) SetActive(userID, actorID int64, actorIsAdmin, active bool) (*domain.User, error) {
+	if !active && userID == actorID {
+		return nil, errors.New("you cannot deactivate your own account")
+	}
+	if err := s.checkTarget(userID, actorIsAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetActive(userID, active); err != nil {
+		return nil, err
+	}
+	if !active && s.sessions != nil {
+		s.sessions.DisconnectUser(userID)
+	}
+
+	return s.GetUser(userID)
+}
+
+// ForcePasswordReset invalidates the user's password and sessions and mails
+// them a reset link.
+func (s *AdminUserService) ForcePasswordReset(userID int64, actorIsAdmin bool) error {
+	if err := s.checkTarget(userID, actorIsAdmin); err != nil {
+		return err
+	}
+
+	if err := s.auth.ForcePasswordReset(userID); err != nil {
+		return err
+	}
+	if s.sessions != nil {
+		s.sessions.DisconnectUser(userID)
+	}
+	return nil
+}
+
+// checkTarget stops user managers who are not admins from acting on admins.
+func (s *AdminUserService) checkTarget(userID int64, actorIsAdmin bool) error {
+	roles, _, err := s.roleRepo.GetUserAccess(userID)
+	if err != nil {
+		return err
+	}
+	if containsRole(roles, domain.RoleAdmin) && !actorIsAdmin {
+		return ErrAdminAccount
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+func TestAuctionCursorRoundTrip(t *testing.T) {
+	last := domain.Auction{
+		ID:           42,
+		CurrentPrice: 1250.5,
+		EndTime:      time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		CreatedAt:    time.Date(2026, 2, 1, 9, 0, 0, 987654321, time.UTC),
+	}
+	tests := []struct {
+		sort string
+		want any
+	}{
+		{"ending_soon", last.EndTime},
+		{"newest", last.CreatedAt},
+		{"price_asc", last.CurrentPrice},
+		{"price_desc", last.CurrentPrice},
+	}
+	for _, tt := range tests {
+		token := encodeAuctionCursor(tt.sort, last)
+		cur, err := decodeAuctionCursor(token, tt.sort)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if cur.ID != last.ID {
+			t.Errorf("%s: id = %d, want %d", tt.sort, cur.ID, last.ID)
+		}
+		switch want := tt.want.(type) {
+		case time.Time:
+			// the full precision must survive, or rows sharing the second
+			// would be skipped or repeated
+			if got, ok := cur.Value.(time.Time); !ok || !got.Equal(want) {
+				t.Errorf("%s: value = %v, want %v", tt.sort, cur.Value, want)
+			}
+		default:
+			if cur.Value != want {
+				t.Errorf("%s: value = %v, want %v", tt.sort, cur.Value, want)
+			}
+		}
+	}
+}
+
+func TestDecodeAuctionCursorRejects(t *testing.T) {
+	last := domain.Auction{ID: 7, CurrentPrice: 10, EndTime: time.Now()}
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name, token, sort string
+	}{
+		{"other sort", encodeAuctionCursor("ending_soon", last), "newest"},
+		{"not base64", "!!!", "newest"},
+		{"not json", raw("hello"), "newest"},
+		{"no id", raw(`{"s":"price_asc","p":1}`), "price_asc"},
+		{"negative id", raw(`{"s":"price_asc","p":1,"id":-1}`), "price_asc"},
+		{"no value", raw(`{"s":"price_asc","id":1}`), "price_asc"},
+		{"price for a time sort", raw(`{"s":"newest","p":1,"id":1}`), "newest"},
+		{"time for a price sort", raw(`{"s":"price_desc","t":"2026-01-01T00:00:00Z","id":1}`), "price_desc"},
+	}
+	for _, tt := range tests {
+		if cur, err := decodeAuctionCursor(tt.token, tt.sort); err == nil {
+			t.Errorf("%s: accepted as %+v", tt.name, cur)
+		}
+	}
+}
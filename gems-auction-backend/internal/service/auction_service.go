@@ -2,31 +2,83 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
 	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAuctionNotOwner      = errors.New("only the auction owner can edit this auction")
+	ErrListingLimitExceeded = errors.New("listing value exceeds the limit for your verification level")
+	ErrRelistOnly           = errors.New("only gems won at auction can be relisted")
 )
 
 type AuctionService struct {
 	auctionRepo *repository.AuctionRepository
+	bidRepo     *repository.BidRepository
+	chatRepo    *repository.ChatRepository
+	broadcast   AuctionEventBroadcaster // can be nil
+	presence    AuctionPresenceSource   // can be nil
+}
+
+// AuctionPresenceSource reports who is watching an auction live.
+type AuctionPresenceSource interface {
+	Presence(auctionID int64) domain.AuctionPresence
 }
 
-func NewAuctionService(auctionRepo *repository.AuctionRepository) *AuctionService {
-	return &AuctionService{auctionRepo: auctionRepo}
+func NewAuctionService(
+	auctionRepo *repository.AuctionRepository,
+	bidRepo *repository.BidRepository,
+	chatRepo *repository.ChatRepository,
+	broadcast AuctionEventBroadcaster,
+	presence AuctionPresenceSource,
+) *AuctionService {
+	return &AuctionService{
+		auctionRepo: auctionRepo,
+		bidRepo:     bidRepo,
+		chatRepo:    chatRepo,
+		broadcast:   broadcast,
+		presence:    presence,
+	}
 }
 
 type CreateAuctionRequest struct {
 	GemID        int64     `json:"gem_id"`
 	StartPrice   float64   `json:"start_price"`
 	MinIncrement float64   `json:"min_increment"`
+	ReservePrice *float64  `json:"reserve_price"`
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
+	// AllowSpectators defaults to true.
+	AllowSpectators *bool `json:"allow_spectators"`
+}
+
+// UpdateAuctionRequest carries the fields a seller wants to change.
+// Nil fields are left untouched.
+type UpdateAuctionRequest struct {
+	StartPrice   *float64   `json:"start_price"`
+	MinIncrement *float64   `json:"min_increment"`
+	ReservePrice *float64   `json:"reserve_price"`
+	StartTime    *time.Time `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+
+	AllowSpectators *bool `json:"allow_spectators"`
 }
 
-func (s *AuctionService) Create(req CreateAuctionRequest) (*domain.Auction, error) {
+// Create lists a gem for auction. Only the gem's current owner (or an admin)
+// may list it, and the gem must be AVAILABLE; it is marked AUCTION until the
+// auction ends. canList is false for users who may only relist
+// (auction:relist): they can list a gem only if they won it at auction.
+func (s *AuctionService) Create(req CreateAuctionRequest, actorID int64, isAdmin, canList bool) (*domain.Auction, error) {
 	if req.GemID <= 0 {
 		return nil, errors.New("gem_id required")
 	}
@@ -36,78 +88,691 @@ func (s *AuctionService) Create(req CreateAuctionRequest) (*domain.Auction, erro
 	if req.MinIncrement <= 0 {
 		return nil, errors.New("min_increment must be > 0")
 	}
+	if req.ReservePrice != nil && *req.ReservePrice < req.StartPrice {
+		return nil, errors.New("reserve_price must be >= start_price")
+	}
 	if req.EndTime.Before(req.StartTime) || req.EndTime.Equal(req.StartTime) {
 		return nil, errors.New("end_time must be after start_time")
 	}
 
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		ownerID   int64
+		gemStatus domain.GemStatus
+	)
+	q := `SELECT seller_id, status FROM gems WHERE id=$1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, q, req.GemID).Scan(&ownerID, &gemStatus); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && ownerID != actorID {
+		return nil, ErrGemNotOwner
+	}
+	if !isAdmin && !canList {
+		var won bool
+		wq := `SELECT EXISTS (SELECT 1 FROM gem_provenance WHERE gem_id=$1 AND event_type=$2 AND to_user_id=$3)`
+		if err := tx.QueryRow(ctx, wq, req.GemID, domain.ProvenancePlatformSale, actorID).Scan(&won); err != nil {
+			return nil, err
+		}
+		if !won {
+			return nil, ErrRelistOnly
+		}
+	}
+	switch gemStatus {
+	case domain.GemAvailable:
+	case domain.GemAuction:
+		return nil, ErrGemInAuction
+	default:
+		return nil, errors.New("gem is not available for auction")
+	}
+
+	if !isAdmin {
+		if err := checkListingLimit(ctx, tx, ownerID, req.StartPrice, req.ReservePrice); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
 	a := &domain.Auction{
 		GemID:        req.GemID,
+		SellerID:     ownerID,
 		StartPrice:   req.StartPrice,
 		CurrentPrice: req.StartPrice,
 		MinIncrement: req.MinIncrement,
+		ReservePrice: req.ReservePrice,
 		StartTime:    req.StartTime,
 		EndTime:      req.EndTime,
 		Status:       domain.AuctionScheduled,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	a.AllowSpectators = req.AllowSpectators == nil || *req.AllowSpectators
+
+	ins := `INSERT INTO auctions (gem_id,seller_id,start_price,current_price,min_increment,reserve_price,start_time,end_time,status,allow_spectators,created_at,updated_at)
+	        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$11)
+	        RETURNING id`
+	if err := tx.QueryRow(ctx, ins,
+		a.GemID, a.SellerID, a.StartPrice, a.CurrentPrice, a.MinIncrement, a.ReservePrice,
+		a.StartTime, a.EndTime, a.Status, a.AllowSpectators, now,
+	).Scan(&a.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE gems SET status=$1, updated_at=$2 WHERE id=$3`, domain.GemAuction, now, a.GemID); err != nil {
+		return nil, err
 	}
 
-	if err := s.auctionRepo.Create(a); err != nil {
+	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 
 	return a, nil
 }
 
-// StartAuction sets status = LIVE (simple helper)
-func (s *AuctionService) StartAuction(auctionID int64) error {
+// checkListingLimit rejects a listing whose value, the higher of start and
+// reserve price, is above what the seller's KYC level allows.
+func checkListingLimit(ctx context.Context, tx pgx.Tx, sellerID int64, startPrice float64, reservePrice *float64) error {
+	var level domain.KYCLevel
+	if err := tx.QueryRow(ctx, `SELECT kyc_level FROM users WHERE id=$1`, sellerID).Scan(&level); err != nil {
+		return err
+	}
+
+	limit, limited := listingLimit(level)
+	if !limited {
+		return nil
+	}
+
+	value := startPrice
+	if reservePrice != nil && *reservePrice > value {
+		value = *reservePrice
+	}
+	if value > limit {
+		return fmt.Errorf("%w: %s allowed at KYC level %d", ErrListingLimitExceeded, formatMoney(limit), level)
+	}
+	return nil
+}
+
+// UpdateAuction applies a seller's edits and records each changed field in
+// the auction's change history. SCHEDULED auctions can be edited freely; once
+// LIVE only end_time may be extended and an unmet reserve may be lowered.
+func (s *AuctionService) UpdateAuction(auctionID, actorID int64, isAdmin bool, req UpdateAuctionRequest) (*domain.Auction, error) {
 	if auctionID <= 0 {
-		return errors.New("invalid auction id")
+		return nil, errors.New("invalid auction id")
 	}
 
-	q := `UPDATE auctions SET status=$1, updated_at=$2 WHERE id=$3`
-	_, err := config.DB.Exec(context.Background(), q, domain.AuctionLive, time.Now(), auctionID)
-	return err
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var a domain.Auction
+
+	q := `SELECT id, gem_id, seller_id, start_price, current_price, min_increment, reserve_price,
+	             start_time, end_time, status, winner_id, allow_spectators, created_at, updated_at
+	      FROM auctions
+	      WHERE id=$1 FOR UPDATE`
+
+	if err := tx.QueryRow(ctx, q, auctionID).Scan(
+		&a.ID,
+		&a.GemID,
+		&a.SellerID,
+		&a.StartPrice,
+		&a.CurrentPrice,
+		&a.MinIncrement,
+		&a.ReservePrice,
+		&a.StartTime,
+		&a.EndTime,
+		&a.Status,
+		&a.WinnerID,
+		&a.AllowSpectators,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && a.SellerID != actorID {
+		return nil, ErrAuctionNotOwner
+	}
+
+	// the history is public, so reserve edits are logged without their
+	// values
+	var changes []domain.AuctionChange
+	record := func(field, oldValue, newValue string) {
+		changes = append(changes, domain.AuctionChange{
+			AuctionID:     a.ID,
+			ChangedBy:     actorID,
+			Field:         field,
+			OldValue:      oldValue,
+			NewValue:      newValue,
+			AuctionStatus: a.Status,
+		})
+	}
+
+	switch a.Status {
+	case domain.AuctionScheduled:
+		if req.StartPrice != nil && *req.StartPrice != a.StartPrice {
+			if *req.StartPrice <= 0 {
+				return nil, errors.New("start_price must be > 0")
+			}
+			record("start_price", formatMoney(a.StartPrice), formatMoney(*req.StartPrice))
+			a.StartPrice = *req.StartPrice
+			a.CurrentPrice = *req.StartPrice
+		}
+		if req.MinIncrement != nil && *req.MinIncrement != a.MinIncrement {
+			if *req.MinIncrement <= 0 {
+				return nil, errors.New("min_increment must be > 0")
+			}
+			record("min_increment", formatMoney(a.MinIncrement), formatMoney(*req.MinIncrement))
+			a.MinIncrement = *req.MinIncrement
+		}
+		if req.ReservePrice != nil && (a.ReservePrice == nil || *req.ReservePrice != *a.ReservePrice) {
+			record("reserve_price", "", "")
+			reserve := *req.ReservePrice
+			a.ReservePrice = &reserve
+		}
+		if req.StartTime != nil && !req.StartTime.Equal(a.StartTime) {
+			record("start_time", a.StartTime.Format(time.RFC3339), req.StartTime.Format(time.RFC3339))
+			a.StartTime = *req.StartTime
+		}
+		if req.EndTime != nil && !req.EndTime.Equal(a.EndTime) {
+			record("end_time", a.EndTime.Format(time.RFC3339), req.EndTime.Format(time.RFC3339))
+			a.EndTime = *req.EndTime
+		}
+
+		if a.ReservePrice != nil && *a.ReservePrice < a.StartPrice {
+			return nil, errors.New("reserve_price must be >= start_price")
+		}
+		if !a.EndTime.After(a.StartTime) {
+			return nil, errors.New("end_time must be after start_time")
+		}
+		if !isAdmin {
+			if err := checkListingLimit(ctx, tx, a.SellerID, a.StartPrice, a.ReservePrice); err != nil {
+				return nil, err
+			}
+		}
+
+	case domain.AuctionLive:
+		if (req.StartPrice != nil && *req.StartPrice != a.StartPrice) ||
+			(req.MinIncrement != nil && *req.MinIncrement != a.MinIncrement) ||
+			(req.StartTime != nil && !req.StartTime.Equal(a.StartTime)) {
+			return nil, errors.New("only end_time and reserve_price can be changed while the auction is live")
+		}
+
+		if req.EndTime != nil && !req.EndTime.Equal(a.EndTime) {
+			if !req.EndTime.After(a.EndTime) {
+				return nil, errors.New("end_time can only be extended while the auction is live")
+			}
+			record("end_time", a.EndTime.Format(time.RFC3339), req.EndTime.Format(time.RFC3339))
+			a.EndTime = *req.EndTime
+		}
+
+		if req.ReservePrice != nil && (a.ReservePrice == nil || *req.ReservePrice != *a.ReservePrice) {
+			if a.ReservePrice == nil {
+				return nil, errors.New("a reserve cannot be added while the auction is live")
+			}
+			if a.CurrentPrice >= *a.ReservePrice {
+				return nil, errors.New("reserve has already been met")
+			}
+			if *req.ReservePrice > *a.ReservePrice {
+				return nil, errors.New("reserve_price can only be lowered while the auction is live")
+			}
+			record("reserve_price", "", "")
+			reserve := *req.ReservePrice
+			a.ReservePrice = &reserve
+		}
+
+	default:
+		return nil, errors.New("auction can no longer be edited")
+	}
+
+	// spectators can be let in or shut out at any time before the end;
+	// anyone already watching stays connected
+	if req.AllowSpectators != nil && *req.AllowSpectators != a.AllowSpectators {
+		record("allow_spectators", strconv.FormatBool(a.AllowSpectators), strconv.FormatBool(*req.AllowSpectators))
+		a.AllowSpectators = *req.AllowSpectators
+	}
+
+	if len(changes) == 0 {
+		return &a, nil
+	}
+
+	now := time.Now()
+	up := `UPDATE auctions
+	       SET start_price=$1, current_price=$2, min_increment=$3, reserve_price=$4,
+	           start_time=$5, end_time=$6, allow_spectators=$7, updated_at=$8
+	       WHERE id=$9`
+	if _, err := tx.Exec(ctx, up,
+		a.StartPrice, a.CurrentPrice, a.MinIncrement, a.ReservePrice,
+		a.StartTime, a.EndTime, a.AllowSpectators, now, a.ID,
+	); err != nil {
+		return nil, err
+	}
+	a.UpdatedAt = now
+
+	ins := `INSERT INTO auction_changes (auction_id, changed_by, field, old_value, new_value, auction_status, created_at)
+	        VALUES ($1,$2,$3,$4,$5,$6,$7)`
+	for _, ch := range changes {
+		if _, err := tx.Exec(ctx, ins,
+			ch.AuctionID, ch.ChangedBy, ch.Field, ch.OldValue, ch.NewValue, ch.AuctionStatus, now,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
 }
 
-// EndAuction sets status = ENDED and optional winner_id
-func (s *AuctionService) EndAuction(auctionID int64, winnerID *int64) error {
+// StartAuction moves a SCHEDULED auction to LIVE. Only the seller who
+// listed it (or an admin) may start it.
+func (s *AuctionService) StartAuction(auctionID, actorID int64, isAdmin bool) error {
 	if auctionID <= 0 {
 		return errors.New("invalid auction id")
 	}
 
-	q := `UPDATE auctions SET status=$1, winner_id=$2, updated_at=$3 WHERE id=$4`
-	_, err := config.DB.Exec(context.Background(), q, domain.AuctionEnded, winnerID, time.Now(), auctionID)
+	ctx := context.Background()
+
+	var (
+		sellerID int64
+		status   domain.AuctionStatus
+	)
+	if err := config.DB.QueryRow(ctx, `SELECT seller_id, status FROM auctions WHERE id=$1`, auctionID).Scan(&sellerID, &status); err != nil {
+		return err
+	}
+	if !isAdmin && sellerID != actorID {
+		return ErrAuctionNotOwner
+	}
+	if status != domain.AuctionScheduled {
+		return errors.New("only scheduled auctions can be started")
+	}
+
+	q := `UPDATE auctions SET status=$1, updated_at=$2 WHERE id=$3 AND status=$4`
+	_, err := config.DB.Exec(ctx, q, domain.AuctionLive, time.Now(), auctionID, domain.AuctionScheduled)
 	return err
 }
 
+// AuctionEndedEvent is broadcast to the auction's viewers when it closes.
+// WinnerID is nil and FinalPrice zero when the lot went unsold.
+type AuctionEndedEvent struct {
+	AuctionID  int64     `json:"auction_id"`
+	WinnerID   *int64    `json:"winner_id"`
+	FinalPrice float64   `json:"final_price"`
+	EndedAt    time.Time `json:"ended_at"`
+}
+
+// EndAuction closes the auction and settles it. The highest bidder wins,
+// provided the reserve (if any) was met. Sellers can only end a live
+// auction once its end_time has passed; moderators can end any auction
+// early. A sale transfers the gem to the winner, who can relist it,
+// appends a PLATFORM_SALE entry to its provenance and opens a pending
+// payment for the winner; an unsold gem goes back to its seller's
+// available inventory. It returns the winner, or nil when the lot went
+// unsold.
+func (s *AuctionService) EndAuction(auctionID, actorID int64, isAdmin bool) (*int64, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		gemID    int64
+		sellerID int64
+		status   domain.AuctionStatus
+		reserve  *float64
+		endTime  time.Time
+	)
+
+	q := `SELECT a.gem_id, a.seller_id, a.status, a.reserve_price, a.end_time
+	      FROM auctions a JOIN gems g ON g.id = a.gem_id
+	      WHERE a.id=$1 FOR UPDATE OF a, g`
+	if err := tx.QueryRow(ctx, q, auctionID).Scan(&gemID, &sellerID, &status, &reserve, &endTime); err != nil {
+		return nil, err
+	}
+	if !isAdmin && sellerID != actorID {
+		return nil, ErrAuctionNotOwner
+	}
+	if status == domain.AuctionEnded {
+		return nil, errors.New("auction already ended")
+	}
+	if !isAdmin {
+		if status != domain.AuctionLive {
+			return nil, errors.New("only a live auction can be ended")
+		}
+		if time.Now().Before(endTime) {
+			return nil, errors.New("auction cannot be ended before its end_time")
+		}
+	}
+
+	var (
+		winner *int64
+		price  float64
+	)
+
+	var top domain.Bid
+	bq := `SELECT user_id, amount FROM bids WHERE auction_id=$1 ORDER BY amount DESC, created_at ASC LIMIT 1`
+	err = tx.QueryRow(ctx, bq, auctionID).Scan(&top.UserID, &top.Amount)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && (reserve == nil || top.Amount >= *reserve) {
+		winner, price = &top.UserID, top.Amount
+	}
+
+	now := time.Now()
+
+	up := `UPDATE auctions SET status=$1, winner_id=$2, updated_at=$3 WHERE id=$4`
+	if _, err := tx.Exec(ctx, up, domain.AuctionEnded, winner, now, auctionID); err != nil {
+		return nil, err
+	}
+
+	if winner == nil {
+		if _, err := tx.Exec(ctx, `UPDATE gems SET status=$1, updated_at=$2 WHERE id=$3`, domain.GemAvailable, now, gemID); err != nil {
+			return nil, err
+		}
+	} else {
+		// ownership passes to the winner; the previous owner stays on the
+		// auction row and in the provenance log
+		tr := `UPDATE gems SET seller_id=$1, status=$2, updated_at=$3 WHERE id=$4`
+		if _, err := tx.Exec(ctx, tr, *winner, domain.GemAvailable, now, gemID); err != nil {
+			return nil, err
+		}
+
+		edit := []domain.GemEdit{{
+			Field:    "seller_id",
+			OldValue: strconv.FormatInt(sellerID, 10),
+			NewValue: strconv.FormatInt(*winner, 10),
+		}}
+		if err := insertGemEdits(ctx, tx, gemID, actorID, now, edit); err != nil {
+			return nil, err
+		}
+
+		// the winner owes the final price; reviews open once the seller
+		// confirms it was paid
+		pay := `INSERT INTO payments (auction_id, user_id, amount, status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$5)`
+		if _, err := tx.Exec(ctx, pay, auctionID, *winner, price, domain.PaymentPending, now); err != nil {
+			return nil, err
+		}
+
+		ins := `INSERT INTO gem_provenance (gem_id, event_type, description, auction_id, from_user_id, to_user_id, amount, occurred_at, recorded_by, created_at)
+		        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$8)`
+		if _, err := tx.Exec(ctx, ins,
+			gemID, domain.ProvenancePlatformSale, fmt.Sprintf("Sold at auction #%d", auctionID),
+			auctionID, sellerID, *winner, price, now, actorID,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.broadcast != nil {
+		s.broadcast.BroadcastToAuction(auctionID, "AUCTION_ENDED", AuctionEndedEvent{
+			AuctionID:  auctionID,
+			WinnerID:   winner,
+			FinalPrice: price,
+			EndedAt:    now,
+		})
+	}
+
+	return winner, nil
+}
+
 func (s *AuctionService) GetByID(auctionID int64) (*domain.Auction, error) {
 	if auctionID <= 0 {
 		return nil, errors.New("invalid auction id")
 	}
 
 	var a domain.Auction
-	q := `SELECT id, gem_id, start_price, current_price, min_increment, start_time, end_time, status, winner_id, created_at, updated_at
-	      FROM auctions WHERE id=$1`
+	q := `SELECT a.id, a.gem_id, a.seller_id, a.start_price, a.current_price, a.min_increment, a.reserve_price, a.start_time, a.end_time,
+	             a.status, a.winner_id, a.allow_spectators, a.created_at, a.updated_at,
+	             ` + repository.VerifiedCertificateSQL + `,
+	             ` + repository.SellerRatingSQL + `
+	      FROM auctions a WHERE a.id=$1`
 
 	err := config.DB.QueryRow(context.Background(), q, auctionID).Scan(
 		&a.ID,
 		&a.GemID,
+		&a.SellerID,
 		&a.StartPrice,
 		&a.CurrentPrice,
 		&a.MinIncrement,
+		&a.ReservePrice,
 		&a.StartTime,
 		&a.EndTime,
 		&a.Status,
 		&a.WinnerID,
+		&a.AllowSpectators,
 		&a.CreatedAt,
 		&a.UpdatedAt,
+		&a.VerifiedCertificate,
+		&a.SellerRating.Average,
+		&a.SellerRating.Count,
 	)
 
 	if err != nil {
 		return nil, err
 	}
+	if s.presence != nil {
+		p := s.presence.Presence(auctionID)
+		a.Presence = &p
+	}
 	return &a, nil
 }
 
-func (s *AuctionService) GetAllAuctions() ([]domain.Auction, error) {
-	return s.auctionRepo.GetAll()
+// SearchAuctionsRequest holds the catalog query string. Status accepts a
+// comma separated list; times are RFC3339.
+type SearchAuctionsRequest struct {
+	Status       string    `form:"status"`
+	MinPrice     *float64  `form:"min_price"`
+	MaxPrice     *float64  `form:"max_price"`
+	EndsAfter    time.Time `form:"ends_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndsBefore   time.Time `form:"ends_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinCarat     *float64  `form:"min_carat"`
+	MaxCarat     *float64  `form:"max_carat"`
+	Color        string    `form:"color"`
+	Clarity      string    `form:"clarity"`
+	Origin       string    `form:"origin"`
+	Species      string    `form:"species"`
+	Variety      string    `form:"variety"`
+	Treatment    string    `form:"treatment"`
+	ColorHue     string    `form:"color_hue"`
+	VerifiedOnly bool      `form:"verified_certificate"`
+	Sort         string    `form:"sort"`
+	Cursor       string    `form:"cursor"`
+	Limit        int       `form:"limit"`
+}
+
+type AuctionPage struct {
+	Items      []domain.Auction `json:"items"`
+	Total      int64            `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+const (
+	defaultAuctionPageSize = 20
+	maxAuctionPageSize     = 100
+)
+
+// auctionCursor is the opaque keyset cursor handed to clients.
+type auctionCursor struct {
+	Sort  string     `json:"s"`
+	Time  *time.Time `json:"t,omitempty"`
+	Price *float64   `json:"p,omitempty"`
+	ID    int64      `json:"id"`
+}
+
+// SearchAuctions returns a keyset-paginated page of the auction catalog.
+func (s *AuctionService) SearchAuctions(req SearchAuctionsRequest) (*AuctionPage, error) {
+	sortName := req.Sort
+	if sortName == "" {
+		sortName = "newest"
+	}
+	sort, ok := repository.AuctionSorts[sortName]
+	if !ok {
+		return nil, errors.New("sort must be one of ending_soon, price_asc, price_desc, newest")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAuctionPageSize
+	}
+	if limit > maxAuctionPageSize {
+		limit = maxAuctionPageSize
+	}
+
+	f := repository.AuctionFilter{
+		MinPrice:     req.MinPrice,
+		MaxPrice:     req.MaxPrice,
+		MinCarat:     req.MinCarat,
+		MaxCarat:     req.MaxCarat,
+		Color:        strings.TrimSpace(req.Color),
+		Clarity:      strings.TrimSpace(req.Clarity),
+		Origin:       strings.TrimSpace(req.Origin),
+		Species:      strings.ToUpper(strings.TrimSpace(req.Species)),
+		Variety:      strings.ToUpper(strings.TrimSpace(req.Variety)),
+		Treatment:    strings.ToUpper(strings.TrimSpace(req.Treatment)),
+		ColorHue:     strings.ToUpper(strings.TrimSpace(req.ColorHue)),
+		VerifiedOnly: req.VerifiedOnly,
+		Sort:         sort,
+		// fetch one extra row to know whether another page exists
+		Limit: limit + 1,
+	}
+
+	if req.Status != "" {
+		for _, st := range strings.Split(req.Status, ",") {
+			status := domain.AuctionStatus(strings.ToUpper(strings.TrimSpace(st)))
+			switch status {
+			case domain.AuctionScheduled, domain.AuctionLive, domain.AuctionEnded:
+				f.Statuses = append(f.Statuses, status)
+			default:
+				return nil, errors.New("invalid status: " + st)
+			}
+		}
+	}
+	if !req.EndsAfter.IsZero() {
+		f.EndsAfter = &req.EndsAfter
+	}
+	if !req.EndsBefore.IsZero() {
+		f.EndsBefore = &req.EndsBefore
+	}
+
+	if req.Cursor != "" {
+		after, err := decodeAuctionCursor(req.Cursor, sortName)
+		if err != nil {
+			return nil, err
+		}
+		f.After = after
+	}
+
+	items, total, err := s.auctionRepo.Search(f)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AuctionPage{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encodeAuctionCursor(sortName, page.Items[limit-1])
+	}
+
+	return page, nil
+}
+
+func encodeAuctionCursor(sortName string, last domain.Auction) string {
+	cur := auctionCursor{Sort: sortName, ID: last.ID}
+	switch sortName {
+	case "ending_soon":
+		cur.Time = &last.EndTime
+	case "newest":
+		cur.Time = &last.CreatedAt
+	default:
+		cur.Price = &last.CurrentPrice
+	}
+
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeAuctionCursor(token, sortName string) (*repository.AuctionCursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cur auctionCursor
+	if err := json.Unmarshal(b, &cur); err != nil || cur.ID <= 0 {
+		return nil, invalid
+	}
+	if cur.Sort != sortName {
+		return nil, errors.New("cursor does not match sort")
+	}
+
+	// the value must be of the sort column's type
+	switch sortName {
+	case "ending_soon", "newest":
+		if cur.Time != nil {
+			return &repository.AuctionCursor{Value: *cur.Time, ID: cur.ID}, nil
+		}
+	default:
+		if cur.Price != nil {
+			return &repository.AuctionCursor{Value: *cur.Price, ID: cur.ID}, nil
+		}
+	}
+	return nil, invalid
+}
+
+// GetChanges returns the public change history of an auction.
+func (s *AuctionService) GetChanges(auctionID int64) ([]domain.AuctionChange, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+	return s.auctionRepo.GetChanges(auctionID)
+}
+
+// GetReserve returns the auction's reserve, or nil without one. The
+// reserve is hidden from bidders, so only the seller and moderators may
+// read it.
+func (s *AuctionService) GetReserve(auctionID, actorID int64, isAdmin bool) (*float64, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+
+	var (
+		sellerID int64
+		reserve  *float64
+	)
+	q := `SELECT seller_id, reserve_price FROM auctions WHERE id=$1`
+	if err := config.DB.QueryRow(context.Background(), q, auctionID).Scan(&sellerID, &reserve); err != nil {
+		return nil, err
+	}
+	if !isAdmin && sellerID != actorID {
+		return nil, ErrAuctionNotOwner
+	}
+	return reserve, nil
+}
+
+func formatMoney(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+const (
+	snapshotBidCount  = 20
+	snapshotChatCount = 50
+)
+
+// AuctionSnapshot is the live state of an auction sent to a client when it
+// joins the auction's room. TimeRemainingMs is measured on the server clock
+// at ServerTime, so clients can correct for their own clock.
+type AuctionSnapshot struct {
+	AuctionID       int64                `json:"auction_id"`
+	Status          domain.AuctionStatus `json:"status"`
+	CurrentPrice    float64              `json:"current_price"`
+	MinNextBid      float64              `json:"min_next_bid"`
+	StartTime       time.Time            `json:"start_time"`
+	EndTime         time.Time            `json:"end_time"`
+	ServerTime      time.Time            `json:"server_time"`
+	TimeRemainingMs int64                `json:"time_remaining_ms"`
+	WinnerID        *int64               `json:"winner_id,omitempty"`
+
+	// RecentBids are newest first; RecentChat is in the order it was sent.
+	RecentBids []domain.Bid         `json:"recent_bids"`
+	RecentChat []domain.ChatMessage `json:"recent_chat"`
+}
+
+func (s *AuctionService) Snapshot(auctionID int64) (*AuctionSnapshot, error) {
+	a, err := s.GetByID(auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	snap := &AuctionSnapshot{
+		AuctionID:    a.ID,
+		Status:       a.Status,
+		CurrentPrice: a.CurrentPrice,
+		// BidService accepts nothing below current_price + min_increment,
+		// the first bid included
+		MinNextBid: a.CurrentPrice + a.MinIncrement,
+		StartTime:  a.StartTime,
+		EndTime:    a.EndTime,
+		ServerTime: now,
+		WinnerID:   a.WinnerID,
+	}
+	if a.Status != domain.AuctionEnded && a.EndTime.After(now) {
+		snap.TimeRemainingMs = a.EndTime.Sub(now).Milliseconds()
+	}
+
+	if snap.RecentBids, err = s.bidRepo.GetRecent(auctionID, snapshotBidCount); err != nil {
+		return nil, err
+	}
+	if snap.RecentChat, err = s.chatRepo.GetRecent(auctionID, snapshotChatCount); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
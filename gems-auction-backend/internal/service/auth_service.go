@@ -1,29 +1,95 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/mailer"
 	"github.com/boswin/gems-auction-backend/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+	ErrInvalidUserToken    = errors.New("invalid or expired link")
+	ErrInvalidMFAToken     = errors.New("invalid or expired two-factor session")
+	ErrPasswordResetNeeded = errors.New("a password reset is required; check your email for a reset link")
+)
+
+const (
+	emailVerifyTokenTTL   = 48 * time.Hour
+	passwordResetTokenTTL = time.Hour
+	// minimum gap between two emails of the same kind to one user
+	userTokenResendDelay = time.Minute
+	// lifetime of the partial token handed out between password and
+	// second factor
+	mfaTokenTTL = 5 * time.Minute
+)
+
+// Stages of a partial login, carried in the "mfa" claim.
+const (
+	MFAStageVerify = "verify"
+	MFAStageEnroll = "enroll"
+)
+
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo    *repository.UserRepository
+	roleRepo    *repository.RoleRepository
+	refreshRepo *repository.RefreshTokenRepository
+	tokenRepo   *repository.UserTokenRepository
+	attemptRepo *repository.LoginAttemptRepository
+	mailer      mailer.Mailer
+	twoFactor   *TwoFactorService
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	roleRepo *repository.RoleRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	tokenRepo *repository.UserTokenRepository,
+	attemptRepo *repository.LoginAttemptRepository,
+	m mailer.Mailer,
+	twoFactor *TwoFactorService,
+) *AuthService {
+	return &AuthService{
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		refreshRepo: refreshRepo,
+		tokenRepo:   tokenRepo,
+		attemptRepo: attemptRepo,
+		mailer:      m,
+		twoFactor:   twoFactor,
+	}
 }
 
+// RegisterRequest is public self-registration. Accounts are always created
+// as BUYER; seller status comes through a seller application.
 type RegisterRequest struct {
-	FullName string          `json:"full_name"`
-	Email    string          `json:"email"`
-	Password string          `json:"password"`
-	Role     domain.UserRole `json:"role"` // ADMIN/SELLER/BUYER
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CreateUserRequest is used by user managers to create accounts with any
+// set of roles.
+type CreateUserRequest struct {
+	FullName string            `json:"full_name"`
+	Email    string            `json:"email"`
+	Password string            `json:"password"`
+	Roles    []domain.UserRole `json:"roles"`
 }
 
 type LoginRequest struct {
@@ -31,28 +97,106 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+type ConfirmTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// MFARequest completes a partial login. Code is a TOTP code or a recovery
+// code; it is not needed to start enrolment.
+type MFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ClientInfo identifies the device a session was started from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AuthResponse carries a short-lived access token (Token) and the refresh
+// token used to obtain the next one. When MFA is set, Token is only a
+// partial token for the /auth/2fa endpoints and no refresh token is issued.
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  domain.User `json:"user"`
+	Token            string      `json:"token"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	RefreshToken     string      `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time  `json:"refresh_expires_at,omitempty"`
+	User             domain.User `json:"user"`
+
+	MFA           string   `json:"mfa,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 func (s *AuthService) Register(req RegisterRequest) (*domain.User, error) {
-	// check existing email
-	existing, err := s.userRepo.GetByEmail(req.Email)
-	if err == nil && existing != nil && existing.ID != 0 {
-		return nil, errors.New("email already registered")
+	return s.createUser(req.FullName, req.Email, req.Password, []domain.UserRole{domain.RoleBuyer})
+}
+
+// CreateUser creates an account on behalf of a user manager. Only admins may
+// create other admins.
+func (s *AuthService) CreateUser(req CreateUserRequest, actorIsAdmin bool) (*domain.User, error) {
+	roles, err := normalizeRoles(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		roles = []domain.UserRole{domain.RoleBuyer}
+	}
+	if containsRole(roles, domain.RoleAdmin) && !actorIsAdmin {
+		return nil, ErrAdminRequired
 	}
 
-	hashed, err := hashPassword(req.Password)
+	return s.createUser(req.FullName, req.Email, req.Password, roles)
+}
+
+func (s *AuthService) createUser(fullName, email, password string, roles []domain.UserRole) (*domain.User, error) {
+	fullName = strings.TrimSpace(fullName)
+	email = normalizeEmail(email)
+
+	var v ValidationError
+	if fullName == "" {
+		v.Add("full_name", "required")
+	} else if len(fullName) > 255 {
+		v.Add("full_name", "must be at most 255 characters")
+	}
+	validateEmail(&v, "email", email)
+	validatePassword(&v, "password", password, email)
+
+	if _, ok := v.Fields["email"]; !ok {
+		// check existing email
+		existing, err := s.userRepo.GetByEmail(email)
+		if err == nil && existing != nil && existing.ID != 0 {
+			v.Add("email", "already registered")
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	hashed, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &domain.User{
-		FullName: req.FullName,
-		Email:    req.Email,
+		FullName: fullName,
+		Email:    email,
 		Password: hashed,
-		Role:     req.Role,
+		Role:     domain.PrimaryRole(roles),
+		Roles:    roles,
 		IsActive: true,
 	}
 
@@ -60,32 +204,539 @@ func (s *AuthService) Register(req RegisterRequest) (*domain.User, error) {
 		return nil, err
 	}
 
+	// the account exists either way; the user can ask for a new link
+	if err := s.sendEmailVerification(user); err != nil {
+		log.Printf("verification email to user %d failed: %v", user.ID, err)
+	}
+
 	// do not return password
 	user.Password = ""
 	return user, nil
 }
 
-func (s *AuthService) Login(req LoginRequest) (*AuthResponse, error) {
-	user, err := s.userRepo.GetByEmail(req.Email)
+// Login checks the password and starts a session. Failures are counted per
+// account and per IP; see login_throttle.go.
+func (s *AuthService) Login(req LoginRequest, client ClientInfo) (*AuthResponse, error) {
+	email := normalizeEmail(req.Email)
+
+	if err := s.checkIPThrottle(client.IP); err != nil {
+		s.recordAttempt(nil, email, client, domain.LoginThrottled)
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.recordAttempt(nil, email, client, domain.LoginUnknownUser)
+		}
 		return nil, errors.New("invalid credentials")
 	}
 
+	if err := checkAccountLock(user); err != nil {
+		s.recordAttempt(user, email, client, domain.LoginLocked)
+		return nil, err
+	}
+
 	if !user.IsActive {
+		s.recordAttempt(user, email, client, domain.LoginDisabled)
 		return nil, errors.New("user is disabled")
 	}
 
 	if err := comparePassword(user.Password, req.Password); err != nil {
+		s.recordAttempt(user, email, client, domain.LoginBadPassword)
+		if err := s.loginFailed(user); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid credentials")
 	}
 
-	token, err := generateJWT(user.ID, user.Email, user.Role)
+	if user.PasswordResetRequired {
+		s.recordAttempt(user, email, client, domain.LoginResetRequired)
+		return nil, ErrPasswordResetNeeded
+	}
+
+	// step-up: with 2FA on (or required by a role) the password only earns
+	// a partial token
+	enabled, required, err := s.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case enabled:
+		s.recordAttempt(user, email, client, domain.LoginMFAPending)
+		return s.issueMFAToken(user, MFAStageVerify)
+	case required:
+		s.recordAttempt(user, email, client, domain.LoginMFAPending)
+		return s.issueMFAToken(user, MFAStageEnroll)
+	}
+
+	return s.startSession(user, client)
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code.
+func (s *AuthService) VerifyTwoFactor(req MFARequest, client ClientInfo) (*AuthResponse, error) {
+	user, err := s.mfaUser(req.MFAToken, MFAStageVerify, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactor.Verify(user.ID, req.Code); err != nil {
+		return nil, s.secondFactorFailed(user, client, err)
+	}
+	return s.startSession(user, client)
+}
+
+// SetupTwoFactor starts enrolment for a user whose role requires 2FA but
+// who has not enrolled yet.
+func (s *AuthService) SetupTwoFactor(req MFARequest) (*TwoFactorSetup, error) {
+	user, err := s.mfaUser(req.MFAToken, MFAStageEnroll, ClientInfo{})
+	if err != nil {
+		return nil, err
+	}
+	return s.twoFactor.Setup(user.ID)
+}
+
+// EnableTwoFactor finishes enrolment during login and starts the session.
+// The response carries the recovery codes.
+func (s *AuthService) EnableTwoFactor(req MFARequest, client ClientInfo) (*AuthResponse, error) {
+	user, err := s.mfaUser(req.MFAToken, MFAStageEnroll, client)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.twoFactor.Enable(user.ID, req.Code)
+	if err != nil {
+		return nil, s.secondFactorFailed(user, client, err)
+	}
+
+	res, err := s.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	res.RecoveryCodes = codes
+	return res, nil
+}
+
+// secondFactorFailed counts a wrong code like a wrong password. Other
+// errors are returned unchanged.
+func (s *AuthService) secondFactorFailed(user *domain.User, client ClientInfo, err error) error {
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		return err
+	}
+	s.recordAttempt(user, user.Email, client, domain.LoginBadSecondFactor)
+	if lockErr := s.loginFailed(user); lockErr != nil {
+		return lockErr
+	}
+	return err
+}
+
+// startSession completes a login: failure counters are reset and the
+// attempt is recorded as successful.
+func (s *AuthService) startSession(user *domain.User, client ClientInfo) (*AuthResponse, error) {
+	rt, raw, err := newRefreshToken(user.ID, randomHex(16), client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.Create(rt); err != nil {
+		return nil, err
+	}
+
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ClearLoginFailures(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.recordAttempt(user, user.Email, client, domain.LoginSuccess)
+
+	return s.issueTokens(user, rt, raw)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. The presented token is revoked; presenting an already revoked token
+// is treated as theft and revokes the whole token family.
+func (s *AuthService) Refresh(req RefreshRequest, client ClientInfo) (*AuthResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	old, err := s.refreshRepo.GetByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if old.RevokedAt != nil {
+		if err := s.refreshRepo.RevokeFamily(old.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenRevoked
+	}
+	if time.Now().After(old.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(old.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("user is disabled")
+	}
+
+	// a role that started requiring 2FA takes effect at the next refresh
+	enabled, required, err := s.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if required && !enabled {
+		_ = s.refreshRepo.RevokeFamily(old.FamilyID)
+		return nil, ErrTwoFactorRequired
+	}
+
+	next, raw, err := newRefreshToken(user.ID, old.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.Rotate(old, next); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// lost a race with another refresh of the same token
+			_ = s.refreshRepo.RevokeFamily(old.FamilyID)
+			return nil, ErrTokenRevoked
+		}
+		return nil, err
+	}
+
+	return s.issueTokens(user, next, raw)
+}
+
+// Logout revokes the session the refresh token belongs to. Unknown tokens
+// are ignored so logout is idempotent.
+func (s *AuthService) Logout(req RefreshRequest) error {
+	if req.RefreshToken == "" {
+		return ErrInvalidRefreshToken
+	}
+
+	t, err := s.refreshRepo.GetByHash(hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return s.refreshRepo.RevokeFamily(t.FamilyID)
+}
+
+// LogoutAll ends every session of the user: refresh tokens are revoked and
+// outstanding access tokens stop working immediately.
+func (s *AuthService) LogoutAll(userID int64) error {
+	if err := s.refreshRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.userRepo.BumpTokenVersion(userID)
+}
+
+// ValidateSession is consulted by AuthMiddleware on every request so that
+// disabled users and revoked tokens are rejected at once.
+func (s *AuthService) ValidateSession(userID int64, tokenVersion int) error {
+	active, version, err := s.userRepo.GetAuthState(userID)
+	if err != nil {
+		return ErrTokenRevoked
+	}
+	if !active {
+		return errors.New("user is disabled")
+	}
+	if tokenVersion != version {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// RequestEmailVerification mails a new verification link. It reports
+// success for unknown or already verified addresses so it cannot be used to
+// probe for accounts.
+func (s *AuthService) RequestEmailVerification(req EmailRequest) error {
+	user, err := s.userRepo.GetByEmail(normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil || !user.IsActive {
+		return nil
+	}
+	return s.sendEmailVerification(user)
+}
+
+// ConfirmEmail consumes a verification token and marks the address verified.
+// It also lifts a login lockout.
+func (s *AuthService) ConfirmEmail(req ConfirmTokenRequest) error {
+	t, err := s.tokenRepo.Consume(hashToken(req.Token), domain.TokenEmailVerify)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidUserToken
+		}
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(t.UserID); err != nil {
+		return err
+	}
+	return s.userRepo.ClearLoginFailures(t.UserID)
+}
+
+// RequestPasswordReset mails a password reset link. Like
+// RequestEmailVerification it never reveals whether the address exists.
+func (s *AuthService) RequestPasswordReset(req EmailRequest) error {
+	user, err := s.userRepo.GetByEmail(normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+	return s.sendPasswordReset(user)
+}
+
+// ForcePasswordReset is used by user managers on a compromised account: the
+// current password and all sessions stop working and a reset link is
+// mailed.
+func (s *AuthService) ForcePasswordReset(userID int64) error {
+	if err := s.userRepo.RequirePasswordReset(userID); err != nil {
+		return err
+	}
+	if err := s.refreshRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.sendPasswordReset(user)
+}
+
+func (s *AuthService) sendPasswordReset(user *domain.User) error {
+	raw, err := s.createUserToken(user.ID, domain.TokenPasswordReset, passwordResetTokenTTL)
+	if err != nil || raw == "" {
+		return err
+	}
+
+	link := config.AppConfig.AppBaseURL + "/reset-password?token=" + url.QueryEscape(raw)
+	return s.mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Gems Auction password",
+		Text: "Hello " + user.FullName + ",\n\n" +
+			"Use the link below to choose a new password. It expires in one hour.\n\n" +
+			link + "\n\n" +
+			"If you did not ask for a password reset you can ignore this email.\n",
+	})
+}
+
+// ResetPassword consumes a reset token and sets the new password. All of the
+// user's sessions are ended. Following the emailed link also proves
+// ownership of the address, so it is marked verified.
+func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
+	var v ValidationError
+	if req.Token == "" {
+		v.Add("token", "required")
+	}
+	validatePassword(&v, "password", req.Password, "")
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	t, err := s.tokenRepo.Consume(hashToken(req.Token), domain.TokenPasswordReset)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidUserToken
+		}
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(t.UserID)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := hashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashed); err != nil {
+		return err
+	}
+	if err := s.refreshRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+	if err := s.userRepo.ClearLoginFailures(user.ID); err != nil {
+		return err
+	}
+	return s.userRepo.MarkEmailVerified(user.ID)
+}
+
+func (s *AuthService) sendEmailVerification(user *domain.User) error {
+	raw, err := s.createUserToken(user.ID, domain.TokenEmailVerify, emailVerifyTokenTTL)
+	if err != nil || raw == "" {
+		return err
+	}
+
+	link := config.AppConfig.AppBaseURL + "/verify-email?token=" + url.QueryEscape(raw)
+	return s.mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Gems Auction email address",
+		Text: "Hello " + user.FullName + ",\n\n" +
+			"Please confirm your email address to start bidding:\n\n" +
+			link + "\n\n" +
+			"The link expires in 48 hours.\n",
+	})
+}
+
+// createUserToken stores a new single-use token and returns its raw value,
+// or "" when one was sent too recently.
+func (s *AuthService) createUserToken(userID int64, purpose domain.UserTokenPurpose, ttl time.Duration) (string, error) {
+	last, err := s.tokenRepo.LastCreatedAt(userID, purpose)
+	if err != nil {
+		return "", err
+	}
+	if last != nil && time.Since(*last) < userTokenResendDelay {
+		return "", nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	t := &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(t); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s *AuthService) issueTokens(user *domain.User, rt *domain.RefreshToken, rawRefresh string) (*AuthResponse, error) {
+	var err error
+	user.Roles, user.Permissions, err = s.roleRepo.GetUserAccess(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := generateJWT(user)
 	if err != nil {
 		return nil, err
 	}
 
 	user.Password = ""
-	return &AuthResponse{Token: token, User: *user}, nil
+	return &AuthResponse{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     rawRefresh,
+		RefreshExpiresAt: &rt.ExpiresAt,
+		User:             *user,
+	}, nil
+}
+
+// issueMFAToken hands out the partial token of a login waiting for its
+// second factor. AuthMiddleware rejects it.
+func (s *AuthService) issueMFAToken(user *domain.User, stage string) (*AuthResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(mfaTokenTTL)
+
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+		"mfa": stage,
+		"ver": user.TokenVersion,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.AppConfig.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &AuthResponse{Token: token, ExpiresAt: expiresAt, User: *user, MFA: stage}, nil
+}
+
+// mfaUser checks a partial token for the given stage and loads its user.
+// Locks and IP throttling apply to the second factor as they do to the
+// password.
+func (s *AuthService) mfaUser(raw, stage string, client ClientInfo) (*domain.User, error) {
+	if err := s.checkIPThrottle(client.IP); err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil || token == nil || !token.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	sub, _ := claims["sub"].(float64)
+	ver, _ := claims["ver"].(float64)
+	if got, _ := claims["mfa"].(string); got != stage || sub <= 0 {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(int64(sub))
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if !user.IsActive {
+		return nil, errors.New("user is disabled")
+	}
+	if user.TokenVersion != int(ver) {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := checkAccountLock(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newRefreshToken creates a random refresh token; only its hash is stored.
+func newRefreshToken(userID int64, familyID string, client ClientInfo) (*domain.RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	return &domain.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(config.AppConfig.RefreshTokenTTL),
+		IP:        client.IP,
+		UserAgent: truncateUserAgent(client.UserAgent),
+	}, raw, nil
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) > 512 {
+		return ua[:512]
+	}
+	return ua
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
 
 func hashPassword(raw string) (string, error) {
@@ -97,14 +748,33 @@ func comparePassword(hashed string, raw string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(raw))
 }
 
-func generateJWT(userID int64, email string, role domain.UserRole) (string, error) {
+// generateJWT issues a short-lived access token carrying the user's roles,
+// the permissions they grant and the token version checked by
+// AuthMiddleware. "role" is the primary role, kept for older clients.
+func generateJWT(user *domain.User) (string, time.Time, error) {
+	roles := make([]string, len(user.Roles))
+	for i, r := range user.Roles {
+		roles[i] = string(r)
+	}
+	perms := make([]string, len(user.Permissions))
+	for i, p := range user.Permissions {
+		perms[i] = string(p)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.AppConfig.AccessTokenTTL)
+
 	claims := jwt.MapClaims{
-		"sub":   userID,
-		"email": email,
-		"role":  string(role),
-		"iat":   time.Now().Unix(),
-		"exp":   time.Now().Add(24 * time.Hour).Unix(),
+		"sub":   user.ID,
+		"email": user.Email,
+		"role":  string(user.Role),
+		"roles": roles,
+		"perms": perms,
+		"ver":   user.TokenVersion,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(config.AppConfig.JWTSecret))
+	signed, err := t.SignedString([]byte(config.AppConfig.JWTSecret))
+	return signed, expiresAt, err
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+var ErrEmailNotVerified = errors.New("verify your email address before bidding")
+
 type AuctionEventBroadcaster interface {
 	BroadcastToAuction(auctionID int64, eventType string, payload any)
 }
@@ -58,21 +60,34 @@ func (s *BidService) PlaceBid(req PlaceBidRequest) (*domain.Bid, error) {
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
+	var verified bool
+	if err := tx.QueryRow(ctx, `SELECT email_verified_at IS NOT NULL FROM users WHERE id=$1`, req.UserID).Scan(&verified); err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, ErrEmailNotVerified
+	}
+
 	// Lock auction row to avoid race conditions (two users bidding same time)
 	var (
 		currentPrice float64
 		minInc       float64
 		status       domain.AuctionStatus
 		endTime      time.Time
+		sellerID     int64
 	)
 
-	q := `SELECT current_price, min_increment, status, end_time
+	q := `SELECT current_price, min_increment, status, end_time, seller_id
 	      FROM auctions WHERE id=$1 FOR UPDATE`
 
-	if err := tx.QueryRow(ctx, q, req.AuctionID).Scan(&currentPrice, &minInc, &status, &endTime); err != nil {
+	if err := tx.QueryRow(ctx, q, req.AuctionID).Scan(&currentPrice, &minInc, &status, &endTime, &sellerID); err != nil {
 		return nil, err
 	}
 
+	if req.UserID == sellerID {
+		return nil, errors.New("sellers cannot bid on their own auction")
+	}
+
 	if status != domain.AuctionLive {
 		return nil, errors.New("auction is not live")
 	}
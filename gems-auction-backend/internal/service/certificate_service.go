@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/boswin/gems-auction-backend/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrCertificateTaken = errors.New("this lab report is already registered to a gem")
+
+var reportNumberSpaces = regexp.MustCompile(`\s+`)
+
+type CertificateService struct {
+	gemRepo  *repository.GemRepository
+	certRepo *repository.CertificateRepository
+	store    storage.BlobStore
+	maxBytes int64
+}
+
+func NewCertificateService(
+	gemRepo *repository.GemRepository,
+	certRepo *repository.CertificateRepository,
+	store storage.BlobStore,
+	maxBytes int64,
+) *CertificateService {
+	return &CertificateService{gemRepo: gemRepo, certRepo: certRepo, store: store, maxBytes: maxBytes}
+}
+
+type RegisterCertificateRequest struct {
+	Lab              string            `form:"lab" json:"lab"`
+	ReportNumber     string            `form:"report_number" json:"report_number"`
+	IssueDate        string            `form:"issue_date" json:"issue_date"` // YYYY-MM-DD
+	StatedAttributes map[string]string `form:"-" json:"stated_attributes"`
+}
+
+type ReviewCertificateRequest struct {
+	Status domain.CertificateStatus `json:"status"`
+	Note   string                   `json:"note"`
+}
+
+// MaxUploadBytes is the largest report PDF accepted.
+func (s *CertificateService) MaxUploadBytes() int64 {
+	return s.maxBytes
+}
+
+// Register attaches a lab report (and optionally its PDF) to a gem. The new
+// certificate starts PENDING until an admin reviews it.
+func (s *CertificateService) Register(gemID, actorID int64, isAdmin bool, req RegisterCertificateRequest, pdf *multipart.FileHeader) (*domain.GemCertificate, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	g, err := s.gemRepo.GetByID(gemID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && g.SellerID != actorID {
+		return nil, ErrGemNotOwner
+	}
+
+	lab := strings.ToUpper(strings.TrimSpace(req.Lab))
+	if !validCertificateLab(lab) {
+		return nil, fmt.Errorf("lab must be one of %s", strings.Join(domain.CertificateLabs, ", "))
+	}
+
+	number := strings.ToUpper(reportNumberSpaces.ReplaceAllString(req.ReportNumber, ""))
+	if number == "" {
+		return nil, errors.New("report_number required")
+	}
+
+	cert := &domain.GemCertificate{
+		GemID:            gemID,
+		Lab:              lab,
+		ReportNumber:     number,
+		StatedAttributes: req.StatedAttributes,
+		Status:           domain.CertificatePending,
+		SubmittedBy:      actorID,
+	}
+	if cert.StatedAttributes == nil {
+		cert.StatedAttributes = map[string]string{}
+	}
+
+	if req.IssueDate != "" {
+		d, err := time.Parse("2006-01-02", req.IssueDate)
+		if err != nil {
+			return nil, errors.New("issue_date must be YYYY-MM-DD")
+		}
+		if d.After(time.Now()) {
+			return nil, errors.New("issue_date cannot be in the future")
+		}
+		cert.IssueDate = &d
+	}
+
+	if pdf != nil {
+		key, err := s.storePDF(gemID, pdf)
+		if err != nil {
+			return nil, err
+		}
+		cert.PDFKey = key
+	}
+
+	if err := s.certRepo.Create(cert); err != nil {
+		if cert.PDFKey != "" {
+			_ = s.store.Delete(context.Background(), cert.PDFKey)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrCertificateTaken
+		}
+		return nil, err
+	}
+
+	s.withURL(cert)
+	return cert, nil
+}
+
+func (s *CertificateService) ListByGem(gemID int64) ([]domain.GemCertificate, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	certs, err := s.certRepo.GetByGem(gemID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range certs {
+		s.withURL(&certs[i])
+	}
+	return certs, nil
+}
+
+// ListForReview is the admin queue; status defaults to PENDING.
+func (s *CertificateService) ListForReview(status string) ([]domain.GemCertificate, error) {
+	st := domain.CertificateStatus(strings.ToUpper(strings.TrimSpace(status)))
+	if st == "" {
+		st = domain.CertificatePending
+	}
+	if !validCertificateStatus(st) {
+		return nil, errors.New("invalid status")
+	}
+
+	certs, err := s.certRepo.GetByStatus(st)
+	if err != nil {
+		return nil, err
+	}
+	for i := range certs {
+		s.withURL(&certs[i])
+	}
+	return certs, nil
+}
+
+// Review records an admin's decision on a certificate.
+func (s *CertificateService) Review(certID, adminID int64, req ReviewCertificateRequest) (*domain.GemCertificate, error) {
+	if certID <= 0 {
+		return nil, errors.New("invalid certificate id")
+	}
+
+	status := domain.CertificateStatus(strings.ToUpper(strings.TrimSpace(string(req.Status))))
+	if !validCertificateStatus(status) {
+		return nil, errors.New("status must be PENDING, VERIFIED or REJECTED")
+	}
+	if status == domain.CertificateRejected && strings.TrimSpace(req.Note) == "" {
+		return nil, errors.New("note required when rejecting a certificate")
+	}
+
+	cert, err := s.certRepo.GetByID(certID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cert.Status = status
+	cert.ReviewedBy = &adminID
+	cert.ReviewedAt = &now
+	cert.ReviewNote = strings.TrimSpace(req.Note)
+
+	if err := s.certRepo.UpdateReview(cert); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrCertificateTaken
+		}
+		return nil, err
+	}
+
+	s.withURL(cert)
+	return cert, nil
+}
+
+func (s *CertificateService) storePDF(gemID int64, fh *multipart.FileHeader) (string, error) {
+	if fh.Size > s.maxBytes {
+		return "", fmt.Errorf("report exceeds %d MB", s.maxBytes>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, s.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return "", fmt.Errorf("report exceeds %d MB", s.maxBytes>>20)
+	}
+	if http.DetectContentType(data) != "application/pdf" {
+		return "", errors.New("report must be a PDF")
+	}
+
+	key := fmt.Sprintf("certificates/%d/%s.pdf", gemID, randomHex(12))
+	if err := s.store.Put(context.Background(), key, bytes.NewReader(data), "application/pdf"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *CertificateService) withURL(c *domain.GemCertificate) {
+	if c.PDFKey != "" {
+		c.PDFURL = s.store.URL(c.PDFKey)
+	}
+}
+
+func validCertificateLab(lab string) bool {
+	for _, l := range domain.CertificateLabs {
+		if l == lab {
+			return true
+		}
+	}
+	return false
+}
+
+func validCertificateStatus(st domain.CertificateStatus) bool {
+	switch st {
+	case domain.CertificatePending, domain.CertificateVerified, domain.CertificateRejected:
+		return true
+	}
+	return false
+}
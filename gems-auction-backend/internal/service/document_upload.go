@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+
+	"github.com/boswin/gems-auction-backend/internal/storage"
+)
+
+// documentUploadTypes maps the accepted document MIME types to file
+// extensions.
+var documentUploadTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// storedDocument describes an uploaded document written to a BlobStore.
+type storedDocument struct {
+	FileName    string
+	Key         string
+	ContentType string
+	SizeBytes   int64
+}
+
+// storeDocument checks that an upload is a PDF, JPEG or PNG of at most
+// maxBytes and stores it under keyPrefix with a random name.
+func storeDocument(store storage.BlobStore, maxBytes int64, keyPrefix string, fh *multipart.FileHeader) (*storedDocument, error) {
+	if fh.Size > maxBytes {
+		return nil, fmt.Errorf("%s exceeds %d MB", fh.Filename, maxBytes>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%s exceeds %d MB", fh.Filename, maxBytes>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := documentUploadTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%s must be a PDF, JPEG or PNG file", fh.Filename)
+	}
+
+	key := keyPrefix + "/" + randomHex(12) + ext
+	if err := store.Put(context.Background(), key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(fh.Filename)
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+
+	return &storedDocument{
+		FileName:    name,
+		Key:         key,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+	}, nil
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register decoders for image.Decode
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"sort"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/boswin/gems-auction-backend/internal/storage"
+	"github.com/boswin/gems-auction-backend/internal/utils"
+)
+
+const (
+	maxImagesPerGem    = 20
+	maxImagesPerUpload = 10
+	maxImagePixels     = 40_000_000 // guards against decompression bombs
+	// maxImageJobs is how many uploaded images are decoded and resized at
+	// once; each can take a few hundred MB while it is.
+	maxImageJobs = 2
+
+	webImageSize   = 1600
+	thumbImageSize = 320
+	jpegQuality    = 85
+)
+
+// allowed upload types and the extension the original is stored with
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+type GemImageService struct {
+	gemRepo   *repository.GemRepository
+	imageRepo *repository.GemImageRepository
+	store     storage.BlobStore
+	maxBytes  int64
+	jobs      chan struct{} // limits concurrent image processing
+}
+
+func NewGemImageService(
+	gemRepo *repository.GemRepository,
+	imageRepo *repository.GemImageRepository,
+	store storage.BlobStore,
+	maxBytes int64,
+) *GemImageService {
+	return &GemImageService{
+		gemRepo:   gemRepo,
+		imageRepo: imageRepo,
+		store:     store,
+		maxBytes:  maxBytes,
+		jobs:      make(chan struct{}, maxImageJobs),
+	}
+}
+
+// MaxUploadBytes is the most the files of one upload may add up to.
+func (s *GemImageService) MaxUploadBytes() int64 {
+	return maxImagesPerUpload * s.maxBytes
+}
+
+// Upload validates, resizes and stores each file, returning the new images.
+// Files are processed in order; the first failure aborts the rest.
+func (s *GemImageService) Upload(gemID, actorID int64, isAdmin bool, files []*multipart.FileHeader) ([]domain.GemImage, error) {
+	if len(files) == 0 {
+		return nil, errors.New("at least one image required")
+	}
+	if len(files) > maxImagesPerUpload {
+		return nil, fmt.Errorf("at most %d images per upload", maxImagesPerUpload)
+	}
+
+	if err := s.authorize(gemID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.imageRepo.GetByGem(gemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing)+len(files) > maxImagesPerGem {
+		return nil, fmt.Errorf("a gem can have at most %d images", maxImagesPerGem)
+	}
+
+	created := make([]domain.GemImage, 0, len(files))
+	for _, fh := range files {
+		img, err := s.storeUpload(gemID, fh)
+		if err != nil {
+			return created, fmt.Errorf("%s: %w", fh.Filename, err)
+		}
+		created = append(created, *img)
+	}
+
+	if err := s.syncPrimaryURL(gemID, actorID); err != nil {
+		return created, err
+	}
+
+	return created, nil
+}
+
+func (s *GemImageService) storeUpload(gemID int64, fh *multipart.FileHeader) (*domain.GemImage, error) {
+	if fh.Size > s.maxBytes {
+		return nil, fmt.Errorf("file exceeds %d MB", s.maxBytes>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("file exceeds %d MB", s.maxBytes>>20)
+	}
+
+	// trust the bytes, not the client supplied Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, errors.New("only JPEG and PNG images are allowed")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("unreadable image")
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errors.New("image dimensions too large")
+	}
+
+	web, thumb, err := s.renditions(data)
+	if err != nil {
+		return nil, err
+	}
+
+	base := fmt.Sprintf("gems/%d/%s", gemID, randomHex(12))
+	img := &domain.GemImage{
+		GemID:       gemID,
+		OriginalKey: base + ext,
+		WebKey:      base + "_web.jpg",
+		ThumbKey:    base + "_thumb.jpg",
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}
+
+	ctx := context.Background()
+	blobs := []struct {
+		key         string
+		data        []byte
+		contentType string
+	}{
+		{img.OriginalKey, data, contentType},
+		{img.WebKey, web, "image/jpeg"},
+		{img.ThumbKey, thumb, "image/jpeg"},
+	}
+	for i, b := range blobs {
+		if err := s.store.Put(ctx, b.key, bytes.NewReader(b.data), b.contentType); err != nil {
+			for _, done := range blobs[:i] {
+				_ = s.store.Delete(ctx, done.key)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.imageRepo.Create(img); err != nil {
+		s.deleteBlobs(img)
+		return nil, err
+	}
+
+	s.withURLs(img)
+	return img, nil
+}
+
+// List returns a gem's images in display order.
+func (s *GemImageService) List(gemID int64) ([]domain.GemImage, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	images, err := s.imageRepo.GetByGem(gemID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		s.withURLs(&images[i])
+	}
+	return images, nil
+}
+
+// Attach loads the gem's images into g.Images.
+func (s *GemImageService) Attach(g *domain.Gem) error {
+	images, err := s.List(g.ID)
+	if err != nil {
+		return err
+	}
+	g.Images = images
+	return nil
+}
+
+func (s *GemImageService) Delete(gemID, imageID, actorID int64, isAdmin bool) error {
+	if err := s.authorize(gemID, actorID, isAdmin); err != nil {
+		return err
+	}
+
+	img, err := s.imageRepo.GetByID(imageID)
+	if err != nil {
+		return err
+	}
+	if img.GemID != gemID {
+		return errors.New("image does not belong to this gem")
+	}
+
+	if err := s.imageRepo.Delete(gemID, imageID); err != nil {
+		return err
+	}
+	s.deleteBlobs(img)
+
+	return s.syncPrimaryURL(gemID, actorID)
+}
+
+func (s *GemImageService) SetPrimary(gemID, imageID, actorID int64, isAdmin bool) ([]domain.GemImage, error) {
+	if err := s.authorize(gemID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.imageRepo.SetPrimary(gemID, imageID); err != nil {
+		return nil, err
+	}
+	if err := s.syncPrimaryURL(gemID, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.List(gemID)
+}
+
+// Reorder sets the display order. imageIDs must list every image of the gem
+// exactly once.
+func (s *GemImageService) Reorder(gemID, actorID int64, isAdmin bool, imageIDs []int64) ([]domain.GemImage, error) {
+	if err := s.authorize(gemID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.GetByGem(gemID)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make([]int64, 0, len(images))
+	for _, img := range images {
+		have = append(have, img.ID)
+	}
+	want := append([]int64(nil), imageIDs...)
+	sort.Slice(have, func(i, j int) bool { return have[i] < have[j] })
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+
+	if len(have) != len(want) {
+		return nil, errors.New("image_ids must list every image of the gem exactly once")
+	}
+	for i := range have {
+		if have[i] != want[i] {
+			return nil, errors.New("image_ids must list every image of the gem exactly once")
+		}
+	}
+
+	if err := s.imageRepo.Reorder(gemID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	return s.List(gemID)
+}
+
+// authorize checks that the actor may change the gem's images. Like other
+// descriptive edits, images are frozen while the gem is in a LIVE auction.
+func (s *GemImageService) authorize(gemID, actorID int64, isAdmin bool) error {
+	if gemID <= 0 {
+		return errors.New("invalid gem id")
+	}
+
+	g, err := s.gemRepo.GetByID(gemID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && g.SellerID != actorID {
+		return ErrGemNotOwner
+	}
+	if g.Status == domain.GemSold {
+		return errors.New("sold gems cannot be edited")
+	}
+
+	live, err := s.gemRepo.HasAuctionInStatus(gemID, domain.AuctionLive)
+	if err != nil {
+		return err
+	}
+	if live {
+		return ErrGemInAuction
+	}
+	return nil
+}
+
+// syncPrimaryURL keeps gems.image_url pointing at the primary web image.
+func (s *GemImageService) syncPrimaryURL(gemID, actorID int64) error {
+	images, err := s.imageRepo.GetByGem(gemID)
+	if err != nil {
+		return err
+	}
+
+	url := ""
+	for _, img := range images {
+		if img.IsPrimary {
+			url = s.store.URL(img.WebKey)
+			break
+		}
+	}
+
+	return s.gemRepo.SetImageURL(gemID, actorID, url)
+}
+
+func (s *GemImageService) withURLs(img *domain.GemImage) {
+	img.OriginalURL = s.store.URL(img.OriginalKey)
+	img.WebURL = s.store.URL(img.WebKey)
+	img.ThumbURL = s.store.URL(img.ThumbKey)
+}
+
+func (s *GemImageService) deleteBlobs(img *domain.GemImage) {
+	ctx := context.Background()
+	for _, key := range []string{img.OriginalKey, img.WebKey, img.ThumbKey} {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Println("blob delete failed:", key, err)
+		}
+	}
+}
+
+// renditions decodes an image and encodes its web size and thumbnail
+// versions. Uploads wait their turn when maxImageJobs are already being
+// processed.
+func (s *GemImageService) renditions(data []byte) (web, thumb []byte, err error) {
+	s.jobs <- struct{}{}
+	defer func() { <-s.jobs }()
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, errors.New("unreadable image")
+	}
+
+	// the thumbnail is scaled from the web version, not the full size image
+	webImg := utils.ResizeToFit(src, webImageSize, webImageSize)
+	if web, err = utils.EncodeJPEG(webImg, jpegQuality); err != nil {
+		return nil, nil, err
+	}
+	if thumb, err = utils.EncodeJPEG(utils.ResizeToFit(webImg, thumbImageSize, thumbImageSize), jpegQuality); err != nil {
+		return nil, nil, err
+	}
+	return web, thumb, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,18 +1,30 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
 	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrGemNotOwner  = errors.New("only the gem owner can change this gem")
+	ErrGemInAuction = errors.New("gem is held by an active auction")
 )
 
 type GemService struct {
 	gemRepo *repository.GemRepository
+	vocab   *VocabularyService
 }
 
-func NewGemService(gemRepo *repository.GemRepository) *GemService {
-	return &GemService{gemRepo: gemRepo}
+func NewGemService(gemRepo *repository.GemRepository, vocab *VocabularyService) *GemService {
+	return &GemService{gemRepo: gemRepo, vocab: vocab}
 }
 
 type CreateGemRequest struct {
@@ -25,6 +37,8 @@ type CreateGemRequest struct {
 	Origin      string  `json:"origin"`
 	Certificate string  `json:"certificate"`
 	ImageURL    string  `json:"image_url"`
+
+	domain.GemAttributes
 }
 
 func (s *GemService) Create(req CreateGemRequest) (*domain.Gem, error) {
@@ -37,18 +51,22 @@ func (s *GemService) Create(req CreateGemRequest) (*domain.Gem, error) {
 	if req.Carat <= 0 {
 		return nil, errors.New("carat must be > 0")
 	}
+	if err := s.vocab.NormalizeAttributes(&req.GemAttributes, nil); err != nil {
+		return nil, err
+	}
 
 	g := &domain.Gem{
-		SellerID:    req.SellerID,
-		Name:        req.Name,
-		Description: req.Description,
-		Carat:       req.Carat,
-		Color:       req.Color,
-		Clarity:     req.Clarity,
-		Origin:      req.Origin,
-		Certificate: req.Certificate,
-		ImageURL:    req.ImageURL,
-		Status:      domain.GemAvailable,
+		SellerID:      req.SellerID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Carat:         req.Carat,
+		Color:         req.Color,
+		Clarity:       req.Clarity,
+		Origin:        req.Origin,
+		Certificate:   req.Certificate,
+		ImageURL:      req.ImageURL,
+		Status:        domain.GemAvailable,
+		GemAttributes: req.GemAttributes,
 	}
 
 	if err := s.gemRepo.Create(g); err != nil {
@@ -64,3 +82,369 @@ func (s *GemService) GetByID(id int64) (*domain.Gem, error) {
 	}
 	return s.gemRepo.GetByID(id)
 }
+
+// UpdateGemRequest carries the descriptive fields a seller wants to change.
+// Nil fields are left untouched.
+type UpdateGemRequest struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Carat       *float64 `json:"carat"`
+	Color       *string  `json:"color"`
+	Clarity     *string  `json:"clarity"`
+	Origin      *string  `json:"origin"`
+	Certificate *string  `json:"certificate"`
+	ImageURL    *string  `json:"image_url"`
+
+	Species         *string  `json:"species"`
+	Variety         *string  `json:"variety"`
+	Treatment       *string  `json:"treatment"`
+	Cut             *string  `json:"cut"`
+	Shape           *string  `json:"shape"`
+	LengthMM        *float64 `json:"length_mm"`
+	WidthMM         *float64 `json:"width_mm"`
+	DepthMM         *float64 `json:"depth_mm"`
+	ColorHue        *string  `json:"color_hue"`
+	ColorTone       *string  `json:"color_tone"`
+	ColorSaturation *string  `json:"color_saturation"`
+}
+
+// ListBySeller returns a seller's inventory, optionally filtered by status.
+func (s *GemService) ListBySeller(sellerID int64, status string) ([]domain.Gem, error) {
+	if sellerID <= 0 {
+		return nil, errors.New("invalid seller id")
+	}
+
+	st := domain.GemStatus(strings.ToUpper(strings.TrimSpace(status)))
+	switch st {
+	case "", domain.GemAvailable, domain.GemAuction, domain.GemSold, domain.GemWithdrawn:
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	return s.gemRepo.GetBySeller(sellerID, st)
+}
+
+// Update edits a gem's descriptive fields and records each change in the
+// gem's edit log. Gems in a LIVE auction cannot be edited.
+func (s *GemService) Update(gemID, actorID int64, isAdmin bool, req UpdateGemRequest) (*domain.Gem, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		g    domain.Gem
+		live bool
+	)
+
+	q := `SELECT seller_id, status, name, COALESCE(description,''), carat, COALESCE(color,''),
+	             COALESCE(clarity,''), COALESCE(origin,''), COALESCE(certificate,''), COALESCE(image_url,''),
+	             COALESCE(species,''), COALESCE(variety,''), COALESCE(treatment,''), COALESCE(cut,''),
+	             COALESCE(shape,''), length_mm, width_mm, depth_mm,
+	             COALESCE(color_hue,''), COALESCE(color_tone,''), COALESCE(color_saturation,''),
+	             EXISTS (SELECT 1 FROM auctions WHERE gem_id = gems.id AND status = $2)
+	      FROM gems WHERE id=$1 FOR UPDATE`
+
+	if err := tx.QueryRow(ctx, q, gemID, domain.AuctionLive).Scan(
+		&g.SellerID,
+		&g.Status,
+		&g.Name,
+		&g.Description,
+		&g.Carat,
+		&g.Color,
+		&g.Clarity,
+		&g.Origin,
+		&g.Certificate,
+		&g.ImageURL,
+		&g.Species,
+		&g.Variety,
+		&g.Treatment,
+		&g.Cut,
+		&g.Shape,
+		&g.LengthMM,
+		&g.WidthMM,
+		&g.DepthMM,
+		&g.ColorHue,
+		&g.ColorTone,
+		&g.ColorSaturation,
+		&live,
+	); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && g.SellerID != actorID {
+		return nil, ErrGemNotOwner
+	}
+	if live {
+		return nil, ErrGemInAuction
+	}
+	if g.Status == domain.GemSold {
+		return nil, errors.New("sold gems cannot be edited")
+	}
+
+	var edits []domain.GemEdit
+	setString := func(field string, cur *string, next *string) {
+		if next == nil {
+			return
+		}
+		v := strings.TrimSpace(*next)
+		if v == *cur {
+			return
+		}
+		edits = append(edits, domain.GemEdit{Field: field, OldValue: *cur, NewValue: v})
+		*cur = v
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return nil, errors.New("name required")
+	}
+	if req.Carat != nil && *req.Carat <= 0 {
+		return nil, errors.New("carat must be > 0")
+	}
+
+	setString("name", &g.Name, req.Name)
+	setString("description", &g.Description, req.Description)
+	if req.Carat != nil && *req.Carat != g.Carat {
+		edits = append(edits, domain.GemEdit{Field: "carat", OldValue: formatOptionalFloat(&g.Carat), NewValue: formatOptionalFloat(req.Carat)})
+		g.Carat = *req.Carat
+	}
+	setString("color", &g.Color, req.Color)
+	setString("clarity", &g.Clarity, req.Clarity)
+	setString("origin", &g.Origin, req.Origin)
+	setString("certificate", &g.Certificate, req.Certificate)
+	setString("image_url", &g.ImageURL, req.ImageURL)
+
+	// structured attributes are validated as a whole so that a new variety
+	// is checked against the (possibly unchanged) species; codes the gem
+	// already had are not re-checked
+	attrs := g.GemAttributes
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&attrs.Species, req.Species},
+		{&attrs.Variety, req.Variety},
+		{&attrs.Treatment, req.Treatment},
+		{&attrs.Cut, req.Cut},
+		{&attrs.Shape, req.Shape},
+		{&attrs.ColorHue, req.ColorHue},
+		{&attrs.ColorTone, req.ColorTone},
+		{&attrs.ColorSaturation, req.ColorSaturation},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	if req.LengthMM != nil {
+		attrs.LengthMM = req.LengthMM
+	}
+	if req.WidthMM != nil {
+		attrs.WidthMM = req.WidthMM
+	}
+	if req.DepthMM != nil {
+		attrs.DepthMM = req.DepthMM
+	}
+	if err := s.vocab.NormalizeAttributes(&attrs, &g.GemAttributes); err != nil {
+		return nil, err
+	}
+
+	setString("species", &g.Species, &attrs.Species)
+	setString("variety", &g.Variety, &attrs.Variety)
+	setString("treatment", &g.Treatment, &attrs.Treatment)
+	setString("cut", &g.Cut, &attrs.Cut)
+	setString("shape", &g.Shape, &attrs.Shape)
+	setDim := func(field string, cur **float64, next *float64) {
+		if next == nil || (*cur != nil && **cur == *next) {
+			return
+		}
+		edits = append(edits, domain.GemEdit{Field: field, OldValue: formatOptionalFloat(*cur), NewValue: formatOptionalFloat(next)})
+		*cur = next
+	}
+	setDim("length_mm", &g.LengthMM, attrs.LengthMM)
+	setDim("width_mm", &g.WidthMM, attrs.WidthMM)
+	setDim("depth_mm", &g.DepthMM, attrs.DepthMM)
+	setString("color_hue", &g.ColorHue, &attrs.ColorHue)
+	setString("color_tone", &g.ColorTone, &attrs.ColorTone)
+	setString("color_saturation", &g.ColorSaturation, &attrs.ColorSaturation)
+
+	if len(edits) > 0 {
+		now := time.Now()
+
+		up := `UPDATE gems
+		       SET name=$1, description=$2, carat=$3, color=$4, clarity=$5, origin=$6,
+		           certificate=$7, image_url=$8, updated_at=$9,
+		           species=NULLIF($11,''), variety=NULLIF($12,''), treatment=NULLIF($13,''),
+		           cut=NULLIF($14,''), shape=NULLIF($15,''), length_mm=$16, width_mm=$17, depth_mm=$18,
+		           color_hue=NULLIF($19,''), color_tone=NULLIF($20,''), color_saturation=NULLIF($21,'')
+		       WHERE id=$10`
+		if _, err := tx.Exec(ctx, up,
+			g.Name, g.Description, g.Carat, g.Color, g.Clarity, g.Origin,
+			g.Certificate, g.ImageURL, now, gemID,
+			g.Species, g.Variety, g.Treatment, g.Cut, g.Shape, g.LengthMM, g.WidthMM, g.DepthMM,
+			g.ColorHue, g.ColorTone, g.ColorSaturation,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := insertGemEdits(ctx, tx, gemID, actorID, now, edits); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.gemRepo.GetByID(gemID)
+}
+
+// Withdraw archives a gem so it no longer appears in the seller's active
+// inventory. It is refused while a SCHEDULED or LIVE auction holds the gem.
+func (s *GemService) Withdraw(gemID, actorID int64, isAdmin bool) (*domain.Gem, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		sellerID int64
+		status   domain.GemStatus
+		held     bool
+	)
+
+	q := `SELECT seller_id, status,
+	             EXISTS (SELECT 1 FROM auctions WHERE gem_id = gems.id AND status IN ($2, $3))
+	      FROM gems WHERE id=$1 FOR UPDATE`
+
+	if err := tx.QueryRow(ctx, q, gemID, domain.AuctionScheduled, domain.AuctionLive).Scan(&sellerID, &status, &held); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && sellerID != actorID {
+		return nil, ErrGemNotOwner
+	}
+	if held {
+		return nil, ErrGemInAuction
+	}
+
+	switch status {
+	case domain.GemWithdrawn:
+		return nil, errors.New("gem already withdrawn")
+	case domain.GemSold:
+		return nil, errors.New("sold gems cannot be withdrawn")
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `UPDATE gems SET status=$1, updated_at=$2 WHERE id=$3`, domain.GemWithdrawn, now, gemID); err != nil {
+		return nil, err
+	}
+
+	edits := []domain.GemEdit{{Field: "status", OldValue: string(status), NewValue: string(domain.GemWithdrawn)}}
+	if err := insertGemEdits(ctx, tx, gemID, actorID, now, edits); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.gemRepo.GetByID(gemID)
+}
+
+// GetEdits returns a gem's edit log.
+func (s *GemService) GetEdits(gemID int64) ([]domain.GemEdit, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+	return s.gemRepo.GetEdits(gemID)
+}
+
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func insertGemEdits(ctx context.Context, tx pgx.Tx, gemID, editedBy int64, at time.Time, edits []domain.GemEdit) error {
+	ins := `INSERT INTO gem_edits (gem_id, edited_by, field, old_value, new_value, created_at)
+	        VALUES ($1,$2,$3,$4,$5,$6)`
+	for _, e := range edits {
+		if _, err := tx.Exec(ctx, ins, gemID, editedBy, e.Field, e.OldValue, e.NewValue, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type SearchGemsRequest struct {
+	Query   string `form:"q"`
+	Origin  string `form:"origin"`
+	Color   string `form:"color"`
+	Clarity string `form:"clarity"`
+	Limit   int    `form:"limit"`
+	Offset  int    `form:"offset"`
+}
+
+type GemSearchResult struct {
+	Query  string                         `json:"query"`
+	Total  int64                          `json:"total"`
+	Items  []domain.GemSearchHit          `json:"items"`
+	Facets map[string][]domain.FacetCount `json:"facets"`
+}
+
+const (
+	defaultGemSearchLimit = 20
+	maxGemSearchLimit     = 100
+)
+
+// Search runs a ranked full-text search over gems with facet counts for
+// origin, color and clarity.
+func (s *GemService) Search(req SearchGemsRequest) (*GemSearchResult, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, errors.New("q required")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultGemSearchLimit
+	}
+	if limit > maxGemSearchLimit {
+		limit = maxGemSearchLimit
+	}
+	if req.Offset < 0 {
+		return nil, errors.New("offset must be >= 0")
+	}
+
+	hits, total, err := s.gemRepo.Search(repository.GemSearchFilter{
+		Query:   query,
+		Origin:  strings.TrimSpace(req.Origin),
+		Color:   strings.TrimSpace(req.Color),
+		Clarity: strings.TrimSpace(req.Clarity),
+		Limit:   limit,
+		Offset:  req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := s.gemRepo.SearchFacets(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GemSearchResult{Query: query, Total: total, Items: hits, Facets: facets}, nil
+}
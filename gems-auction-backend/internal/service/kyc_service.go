@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/boswin/gems-auction-backend/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrKYCPending      = errors.New("a KYC submission is already pending review")
+	ErrKYCLevelReached = errors.New("account is already verified at this level")
+)
+
+const (
+	maxKYCDocuments = 10
+	minKYCAge       = 18
+)
+
+type KYCService struct {
+	kycRepo  *repository.KYCRepository
+	store    storage.BlobStore
+	maxBytes int64
+}
+
+// NewKYCService takes a private BlobStore: identity documents must not be
+// reachable through the public media route.
+func NewKYCService(kycRepo *repository.KYCRepository, store storage.BlobStore, maxBytes int64) *KYCService {
+	return &KYCService{kycRepo: kycRepo, store: store, maxBytes: maxBytes}
+}
+
+// KYCRequest is submitted as multipart/form-data. Level 1 verifies the
+// person, level 2 their business; the i-th entry of DocumentTypes describes
+// the i-th uploaded document.
+type KYCRequest struct {
+	Level              domain.KYCLevel `form:"level"`
+	LegalName          string          `form:"legal_name"`
+	DateOfBirth        string          `form:"date_of_birth"`
+	BusinessName       string          `form:"business_name"`
+	RegistrationNumber string          `form:"registration_number"`
+	DocumentTypes      []string        `form:"document_types"`
+}
+
+type ReviewKYCRequest struct {
+	Status domain.KYCStatus `json:"status"`
+	Note   string           `json:"note"`
+}
+
+// KYCOverview is what a user sees about their own verification. A nil
+// ListingLimit means listings are not limited.
+type KYCOverview struct {
+	Level        domain.KYCLevel       `json:"level"`
+	ListingLimit *float64              `json:"listing_limit"`
+	Latest       *domain.KYCSubmission `json:"latest_submission,omitempty"`
+}
+
+// listingLimit is the highest listing value a seller at level may list.
+func listingLimit(level domain.KYCLevel) (float64, bool) {
+	switch level {
+	case domain.KYCUnverified:
+		return config.AppConfig.ListingLimitUnverified, true
+	case domain.KYCIdentity:
+		return config.AppConfig.ListingLimitIdentity, true
+	}
+	return 0, false
+}
+
+// MaxUploadBytes is the most the documents of one submission may add up to.
+func (s *KYCService) MaxUploadBytes() int64 {
+	return maxKYCDocuments * s.maxBytes
+}
+
+// Submit files a KYC submission with its documents. Business verification
+// requires identity verification first.
+func (s *KYCService) Submit(userID int64, req KYCRequest, files []*multipart.FileHeader) (*domain.KYCSubmission, error) {
+	current, err := s.kycRepo.GetLevel(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &domain.KYCSubmission{
+		UserID:             userID,
+		Level:              req.Level,
+		LegalName:          strings.TrimSpace(req.LegalName),
+		BusinessName:       strings.TrimSpace(req.BusinessName),
+		RegistrationNumber: strings.TrimSpace(req.RegistrationNumber),
+		Status:             domain.KYCPending,
+	}
+
+	var v ValidationError
+	switch k.Level {
+	case domain.KYCIdentity, domain.KYCBusiness:
+		if k.Level <= current {
+			return nil, ErrKYCLevelReached
+		}
+		if k.Level == domain.KYCBusiness && current < domain.KYCIdentity {
+			v.Add("level", "identity verification (level 1) required first")
+		}
+	default:
+		v.Add("level", "must be 1 (identity) or 2 (business)")
+	}
+
+	if k.LegalName == "" {
+		v.Add("legal_name", "required")
+	} else if len(k.LegalName) > 255 {
+		v.Add("legal_name", "must be at most 255 characters")
+	}
+
+	if k.Level == domain.KYCIdentity {
+		dob, err := time.Parse("2006-01-02", strings.TrimSpace(req.DateOfBirth))
+		switch {
+		case err != nil:
+			v.Add("date_of_birth", "must be a date like 1990-04-21")
+		case dob.AddDate(minKYCAge, 0, 0).After(time.Now()):
+			v.Add("date_of_birth", fmt.Sprintf("you must be at least %d", minKYCAge))
+		default:
+			k.DateOfBirth = &dob
+		}
+	}
+	if k.Level == domain.KYCBusiness {
+		if k.BusinessName == "" {
+			v.Add("business_name", "required")
+		} else if len(k.BusinessName) > 255 {
+			v.Add("business_name", "must be at most 255 characters")
+		}
+		if k.RegistrationNumber == "" {
+			v.Add("registration_number", "required")
+		} else if len(k.RegistrationNumber) > 100 {
+			v.Add("registration_number", "must be at most 100 characters")
+		}
+	}
+
+	switch {
+	case len(files) == 0:
+		v.Add("documents", "at least one document required")
+	case len(files) > maxKYCDocuments:
+		v.Add("documents", fmt.Sprintf("at most %d documents", maxKYCDocuments))
+	}
+	docTypes := make([]string, len(files))
+	for i := range files {
+		docTypes[i] = "OTHER"
+		if i < len(req.DocumentTypes) && strings.TrimSpace(req.DocumentTypes[i]) != "" {
+			docTypes[i] = strings.ToUpper(strings.TrimSpace(req.DocumentTypes[i]))
+		}
+		if !containsString(domain.KYCDocumentTypes, docTypes[i]) {
+			v.Add("document_types", "must be one of "+strings.Join(domain.KYCDocumentTypes, ", "))
+		}
+	}
+	if len(files) > 0 {
+		switch k.Level {
+		case domain.KYCIdentity:
+			if !containsAnyString(docTypes, domain.KYCIdentityDocumentTypes) {
+				v.Add("document_types", "one of "+strings.Join(domain.KYCIdentityDocumentTypes, ", ")+" required")
+			}
+		case domain.KYCBusiness:
+			if !containsString(docTypes, "BUSINESS_REGISTRATION") {
+				v.Add("document_types", "BUSINESS_REGISTRATION required")
+			}
+		}
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	for i, fh := range files {
+		stored, err := storeDocument(s.store, s.maxBytes, fmt.Sprintf("kyc/%d", userID), fh)
+		if err != nil {
+			s.deleteDocuments(ctx, k.Documents)
+			return nil, err
+		}
+		k.Documents = append(k.Documents, domain.KYCDocument{
+			DocType:     docTypes[i],
+			FileName:    stored.FileName,
+			Key:         stored.Key,
+			ContentType: stored.ContentType,
+			SizeBytes:   stored.SizeBytes,
+		})
+	}
+
+	if err := s.kycRepo.Create(k); err != nil {
+		s.deleteDocuments(ctx, k.Documents)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrKYCPending
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// GetMine returns the user's KYC level, the listing limit it allows and
+// their latest submission.
+func (s *KYCService) GetMine(userID int64) (*KYCOverview, error) {
+	level, err := s.kycRepo.GetLevel(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &KYCOverview{Level: level}
+	if limit, ok := listingLimit(level); ok {
+		o.ListingLimit = &limit
+	}
+
+	latest, err := s.kycRepo.GetLatestByUser(userID)
+	if err == nil {
+		o.Latest = latest
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ListForReview is the admin queue; status defaults to PENDING.
+func (s *KYCService) ListForReview(status string) ([]domain.KYCSubmission, error) {
+	st := domain.KYCStatus(strings.ToUpper(strings.TrimSpace(status)))
+	if st == "" {
+		st = domain.KYCPending
+	}
+	switch st {
+	case domain.KYCPending, domain.KYCApproved, domain.KYCRejected:
+	default:
+		return nil, errors.New("invalid status")
+	}
+	return s.kycRepo.GetByStatus(st)
+}
+
+// Review approves or rejects a pending submission. Approval raises the
+// user's KYC level and with it their listing limit.
+func (s *KYCService) Review(submissionID, adminID int64, req ReviewKYCRequest) (*domain.KYCSubmission, error) {
+	if submissionID <= 0 {
+		return nil, errors.New("invalid submission id")
+	}
+
+	status := domain.KYCStatus(strings.ToUpper(strings.TrimSpace(string(req.Status))))
+	if status != domain.KYCApproved && status != domain.KYCRejected {
+		return nil, errors.New("status must be APPROVED or REJECTED")
+	}
+	note := strings.TrimSpace(req.Note)
+	if status == domain.KYCRejected && note == "" {
+		return nil, errors.New("note required when rejecting a submission")
+	}
+
+	k, err := s.kycRepo.GetByID(submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if k.Status != domain.KYCPending {
+		return nil, errors.New("submission has already been reviewed")
+	}
+
+	now := time.Now()
+	k.Status = status
+	k.ReviewedBy = &adminID
+	k.ReviewedAt = &now
+	k.ReviewNote = note
+
+	if err := s.kycRepo.Review(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// OpenDocument returns a document of a submission and its contents. The
+// caller must close the reader.
+func (s *KYCService) OpenDocument(submissionID, docID int64) (*domain.KYCDocument, io.ReadCloser, error) {
+	doc, err := s.kycRepo.GetDocument(submissionID, docID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err := s.store.Open(context.Background(), doc.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, rc, nil
+}
+
+func (s *KYCService) deleteDocuments(ctx context.Context, docs []domain.KYCDocument) {
+	for _, d := range docs {
+		_ = s.store.Delete(ctx, d.Key)
+	}
+}
+
+func containsAnyString(list, wanted []string) bool {
+	for _, w := range wanted {
+		if containsString(list, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/mailer"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// consecutive failures before an account is locked; each further
+	// failure doubles the lock
+	accountLockThreshold = 5
+	accountLockBase      = time.Minute
+	accountLockMax       = 24 * time.Hour
+
+	// failures from one IP within the window before it is slowed down
+	ipFailureWindow    = 15 * time.Minute
+	ipFailureThreshold = 20
+	ipBackoffBase      = time.Second
+	ipBackoffMax       = 15 * time.Minute
+
+	accountUnlockTokenTTL = 24 * time.Hour
+	maxLoginHistory       = 200
+	defaultLoginHistory   = 50
+)
+
+// TooManyAttemptsError is returned while an account is locked or an IP is
+// backing off.
+type TooManyAttemptsError struct {
+	RetryAt time.Time
+	// Locked is set for an account lock, which can also be lifted through
+	// the unlock email or by an admin.
+	Locked bool
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	if e.Locked {
+		return "account temporarily locked after too many failed logins; check your email to unlock it"
+	}
+	return "too many failed login attempts, try again later"
+}
+
+// RetryAfter is the wait before the next attempt can succeed.
+func (e *TooManyAttemptsError) RetryAfter() time.Duration {
+	d := time.Until(e.RetryAt)
+	if d < time.Second {
+		return time.Second
+	}
+	return d.Round(time.Second)
+}
+
+type LoginHistoryRequest struct {
+	Limit int `form:"limit"`
+}
+
+// backoff returns how long to wait after failures, doubling from base once
+// threshold is reached and never exceeding max. It is zero below the
+// threshold.
+func backoff(failures, threshold int, base, max time.Duration) time.Duration {
+	n := failures - threshold
+	if n < 0 {
+		return 0
+	}
+	d := base
+	for ; n > 0; n-- {
+		// doubling past max/2 would exceed max, or overflow
+		if d > max/2 {
+			return max
+		}
+		d *= 2
+	}
+	return min(d, max)
+}
+
+// checkIPThrottle refuses attempts from an address that has failed too often
+// recently until its backoff has passed.
+func (s *AuthService) checkIPThrottle(ip string) error {
+	if ip == "" {
+		return nil
+	}
+	count, last, err := s.attemptRepo.FailuresByIP(ip, time.Now().Add(-ipFailureWindow), domain.FailedLoginResults)
+	if err != nil {
+		return err
+	}
+	if last == nil {
+		return nil
+	}
+	if wait := backoff(count, ipFailureThreshold, ipBackoffBase, ipBackoffMax); wait > 0 {
+		if retryAt := last.Add(wait); time.Now().Before(retryAt) {
+			return &TooManyAttemptsError{RetryAt: retryAt}
+		}
+	}
+	return nil
+}
+
+// checkAccountLock refuses logins to a locked account.
+func checkAccountLock(user *domain.User) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return &TooManyAttemptsError{RetryAt: *user.LockedUntil, Locked: true}
+	}
+	return nil
+}
+
+// loginFailed counts a failed password or second factor against the account
+// and locks it once the threshold is reached. The first lock also mails an
+// unlock link. It returns the lock error, or nil while the account is still
+// open.
+func (s *AuthService) loginFailed(user *domain.User) error {
+	count, err := s.userRepo.IncrementFailedLogins(user.ID)
+	if err != nil {
+		return err
+	}
+
+	wait := backoff(count, accountLockThreshold, accountLockBase, accountLockMax)
+	if wait == 0 {
+		return nil
+	}
+	until := time.Now().Add(wait)
+	if err := s.userRepo.LockUntil(user.ID, until); err != nil {
+		return err
+	}
+
+	if count == accountLockThreshold {
+		if err := s.sendUnlockEmail(user); err != nil {
+			log.Printf("unlock email for user %d failed: %v", user.ID, err)
+		}
+	}
+	return &TooManyAttemptsError{RetryAt: until, Locked: true}
+}
+
+// recordAttempt adds an entry to the login history. Failing to record must
+// not fail the login itself.
+func (s *AuthService) recordAttempt(user *domain.User, email string, client ClientInfo, result domain.LoginResult) {
+	a := &domain.LoginAttempt{
+		Email:     email,
+		IP:        client.IP,
+		UserAgent: truncateUserAgent(client.UserAgent),
+		Result:    result,
+	}
+	if user != nil {
+		a.UserID = &user.ID
+		a.Email = user.Email
+	}
+	if err := s.attemptRepo.Create(a); err != nil {
+		log.Printf("recording login attempt failed: %v", err)
+	}
+}
+
+func (s *AuthService) sendUnlockEmail(user *domain.User) error {
+	raw, err := s.createUserToken(user.ID, domain.TokenAccountUnlock, accountUnlockTokenTTL)
+	if err != nil || raw == "" {
+		return err
+	}
+
+	link := config.AppConfig.AppBaseURL + "/unlock-account?token=" + url.QueryEscape(raw)
+	return s.mailer.Send(context.Background(), mailer.Message{
+		To:      user.Email,
+		Subject: "Your Gems Auction account has been locked",
+		Text: "Hello " + user.FullName + ",\n\n" +
+			fmt.Sprintf("We locked your account after %d failed login attempts.\n", accountLockThreshold) +
+			"If that was you, use the link below to unlock it now:\n\n" +
+			link + "\n\n" +
+			"If it was not you, unlock your account and consider changing your password.\n",
+	})
+}
+
+// ConfirmUnlock consumes an unlock token from the lockout email and lifts
+// the lock. Following the link also proves ownership of the address.
+func (s *AuthService) ConfirmUnlock(req ConfirmTokenRequest) error {
+	t, err := s.tokenRepo.Consume(hashToken(req.Token), domain.TokenAccountUnlock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidUserToken
+		}
+		return err
+	}
+	if err := s.userRepo.ClearLoginFailures(t.UserID); err != nil {
+		return err
+	}
+	return s.userRepo.MarkEmailVerified(t.UserID)
+}
+
+// UnlockUser lifts a lockout on behalf of a user manager.
+func (s *AuthService) UnlockUser(userID int64) error {
+	return s.userRepo.ClearLoginFailures(userID)
+}
+
+// LoginHistory returns the user's recent login attempts, newest first.
+func (s *AuthService) LoginHistory(userID int64, req LoginHistoryRequest) ([]domain.LoginAttempt, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLoginHistory
+	}
+	if limit > maxLoginHistory {
+		limit = maxLoginHistory
+	}
+	return s.attemptRepo.ListByUser(userID, limit)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures, threshold int
+		base, max           time.Duration
+		want                time.Duration
+	}{
+		{0, 5, time.Minute, 24 * time.Hour, 0},
+		{4, 5, time.Minute, 24 * time.Hour, 0},
+		{5, 5, time.Minute, 24 * time.Hour, time.Minute},
+		{6, 5, time.Minute, 24 * time.Hour, 2 * time.Minute},
+		{15, 5, time.Minute, 24 * time.Hour, 1024 * time.Minute},
+		// 2048 minutes is past the cap
+		{16, 5, time.Minute, 24 * time.Hour, 24 * time.Hour},
+		{35, 5, time.Minute, 24 * time.Hour, 24 * time.Hour},
+		// shifts that would overflow still cap
+		{36, 5, time.Minute, 24 * time.Hour, 24 * time.Hour},
+		{1000, 5, time.Minute, 24 * time.Hour, 24 * time.Hour},
+		{20, 20, time.Second, 15 * time.Minute, time.Second},
+		{29, 20, time.Second, 15 * time.Minute, 512 * time.Second},
+		{30, 20, time.Second, 15 * time.Minute, 15 * time.Minute},
+		{100, 0, 24 * time.Hour, 365 * 24 * time.Hour, 365 * 24 * time.Hour},
+		// 24h << 18 wraps around to about 133 years, below this cap
+		{18, 0, 24 * time.Hour, 1 << 62, 1 << 62},
+		{3, 0, 24 * time.Hour, 1 << 62, 8 * 24 * time.Hour},
+		{0, 0, 2 * time.Hour, time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.failures, tt.threshold, tt.base, tt.max); got != tt.want {
+			t.Errorf("backoff(%d, %d, %v, %v) = %v, want %v",
+				tt.failures, tt.threshold, tt.base, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffNeverDecreases(t *testing.T) {
+	prev := time.Duration(0)
+	for failures := 0; failures < 100; failures++ {
+		d := backoff(failures, accountLockThreshold, accountLockBase, accountLockMax)
+		if d < prev || d > accountLockMax {
+			t.Fatalf("backoff(%d) = %v after %v", failures, d, prev)
+		}
+		prev = d
+	}
+}
@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/boswin/gems-auction-backend/config"
 	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/jackc/pgx/v5"
 )
 
 type PaymentService struct{}
@@ -71,3 +73,84 @@ func (s *PaymentService) MarkFailed(paymentID int64) error {
 	_, err := config.DB.Exec(context.Background(), q, domain.PaymentFailed, time.Now(), paymentID)
 	return err
 }
+
+var (
+	ErrPaymentNotParty   = errors.New("only the buyer and seller can see this payment")
+	ErrPaymentNotSeller  = errors.New("only the seller can confirm this payment")
+	ErrPaymentNotPending = errors.New("payment is not pending")
+)
+
+const auctionPaymentQuery = `
+	SELECT p.id, p.auction_id, p.user_id, p.amount, p.status, COALESCE(p.reference,''), p.created_at, p.updated_at, a.seller_id
+	FROM payments p JOIN auctions a ON a.id = p.auction_id
+	WHERE p.auction_id=$1 AND p.user_id = a.winner_id
+	ORDER BY p.id DESC LIMIT 1`
+
+// GetForAuction returns the winner's payment for a sold auction. Only the
+// winner, the seller and payment admins may see it.
+func (s *PaymentService) GetForAuction(auctionID, actorID int64, isAdmin bool) (*domain.Payment, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+
+	var (
+		p        domain.Payment
+		sellerID int64
+	)
+	if err := config.DB.QueryRow(context.Background(), auctionPaymentQuery, auctionID).Scan(
+		&p.ID, &p.AuctionID, &p.UserID, &p.Amount, &p.Status, &p.Reference, &p.CreatedAt, &p.UpdatedAt, &sellerID,
+	); err != nil {
+		return nil, err
+	}
+	if !isAdmin && actorID != p.UserID && actorID != sellerID {
+		return nil, ErrPaymentNotParty
+	}
+	return &p, nil
+}
+
+// ConfirmForAuction records that the seller received the winner's payment,
+// which opens the auction to reviews. Payment admins can confirm on the
+// seller's behalf.
+func (s *PaymentService) ConfirmForAuction(auctionID, actorID int64, isAdmin bool, reference string) (*domain.Payment, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		p        domain.Payment
+		sellerID int64
+	)
+	if err := tx.QueryRow(ctx, auctionPaymentQuery+` FOR UPDATE OF p`, auctionID).Scan(
+		&p.ID, &p.AuctionID, &p.UserID, &p.Amount, &p.Status, &p.Reference, &p.CreatedAt, &p.UpdatedAt, &sellerID,
+	); err != nil {
+		return nil, err
+	}
+	if !isAdmin && actorID != sellerID {
+		return nil, ErrPaymentNotSeller
+	}
+	if p.Status != domain.PaymentPending {
+		return nil, ErrPaymentNotPending
+	}
+
+	p.Status = domain.PaymentCompleted
+	p.UpdatedAt = time.Now()
+	if reference = strings.TrimSpace(reference); reference != "" {
+		p.Reference = reference
+	}
+
+	q := `UPDATE payments SET status=$1, reference=NULLIF($2,''), updated_at=$3 WHERE id=$4`
+	if _, err := tx.Exec(ctx, q, p.Status, p.Reference, p.UpdatedAt, p.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+)
+
+const maxAddresses = 20
+
+var (
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	taxIDPattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ./-]{2,98}[A-Za-z0-9]$`)
+)
+
+type ProfileService struct {
+	userRepo    *repository.UserRepository
+	addressRepo *repository.AddressRepository
+	reviewRepo  *repository.ReviewRepository
+}
+
+func NewProfileService(
+	userRepo *repository.UserRepository,
+	addressRepo *repository.AddressRepository,
+	reviewRepo *repository.ReviewRepository,
+) *ProfileService {
+	return &ProfileService{userRepo: userRepo, addressRepo: addressRepo, reviewRepo: reviewRepo}
+}
+
+// UpdateProfileRequest carries the fields a user wants to change. Nil
+// fields are left untouched; an empty phone or tax_id clears it.
+type UpdateProfileRequest struct {
+	FullName *string `json:"full_name"`
+	Phone    *string `json:"phone"`
+	TaxID    *string `json:"tax_id"`
+}
+
+type AddressRequest struct {
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+// GetProfile returns the user together with the ratings they received.
+func (s *ProfileService) GetProfile(userID int64) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seller, buyer, err := s.reviewRepo.GetRatings(userID)
+	if err != nil {
+		return nil, err
+	}
+	user.SellerRating = &seller
+	user.BuyerRating = &buyer
+	return user, nil
+}
+
+func (s *ProfileService) UpdateProfile(userID int64, req UpdateProfileRequest) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var v ValidationError
+	if req.FullName != nil {
+		user.FullName = strings.TrimSpace(*req.FullName)
+		if user.FullName == "" {
+			v.Add("full_name", "required")
+		} else if len(user.FullName) > 255 {
+			v.Add("full_name", "must be at most 255 characters")
+		}
+	}
+	if req.Phone != nil {
+		user.Phone = strings.TrimSpace(*req.Phone)
+		if user.Phone != "" && !phonePattern.MatchString(user.Phone) {
+			v.Add("phone", "must be a valid phone number")
+		}
+	}
+	if req.TaxID != nil {
+		user.TaxID = strings.ToUpper(strings.TrimSpace(*req.TaxID))
+		if user.TaxID != "" && !taxIDPattern.MatchString(user.TaxID) {
+			v.Add("tax_id", "must be 4-100 letters, digits, spaces, dots, slashes or dashes")
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *ProfileService) ListAddresses(userID int64) ([]domain.Address, error) {
+	return s.addressRepo.ListByUser(userID)
+}
+
+// CreateAddress adds an address to the user's address book. The first
+// address becomes the default.
+func (s *ProfileService) CreateAddress(userID int64, req AddressRequest) (*domain.Address, error) {
+	a, err := buildAddress(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.addressRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAddresses {
+		return nil, fmt.Errorf("at most %d addresses", maxAddresses)
+	}
+
+	a.UserID = userID
+	if err := s.addressRepo.Create(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// UpdateAddress replaces an address. It returns pgx.ErrNoRows when the
+// address does not belong to the user.
+func (s *ProfileService) UpdateAddress(userID, addressID int64, req AddressRequest) (*domain.Address, error) {
+	if addressID <= 0 {
+		return nil, errors.New("invalid address id")
+	}
+
+	current, err := s.addressRepo.GetByID(userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := buildAddress(req)
+	if err != nil {
+		return nil, err
+	}
+	a.ID = current.ID
+	a.UserID = userID
+	a.CreatedAt = current.CreatedAt
+
+	if err := s.addressRepo.Update(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *ProfileService) DeleteAddress(userID, addressID int64) error {
+	if addressID <= 0 {
+		return errors.New("invalid address id")
+	}
+	return s.addressRepo.Delete(userID, addressID)
+}
+
+func buildAddress(req AddressRequest) (*domain.Address, error) {
+	a := &domain.Address{
+		Label:         strings.TrimSpace(req.Label),
+		RecipientName: strings.TrimSpace(req.RecipientName),
+		Line1:         strings.TrimSpace(req.Line1),
+		Line2:         strings.TrimSpace(req.Line2),
+		City:          strings.TrimSpace(req.City),
+		Region:        strings.TrimSpace(req.Region),
+		PostalCode:    strings.TrimSpace(req.PostalCode),
+		Country:       strings.ToUpper(strings.TrimSpace(req.Country)),
+		Phone:         strings.TrimSpace(req.Phone),
+		IsDefault:     req.IsDefault,
+	}
+
+	var v ValidationError
+	requireMax := func(field, value string, max int, required bool) {
+		switch {
+		case value == "" && required:
+			v.Add(field, "required")
+		case len(value) > max:
+			v.Add(field, fmt.Sprintf("must be at most %d characters", max))
+		}
+	}
+	requireMax("label", a.Label, 50, false)
+	requireMax("recipient_name", a.RecipientName, 255, true)
+	requireMax("line1", a.Line1, 255, true)
+	requireMax("line2", a.Line2, 255, false)
+	requireMax("city", a.City, 100, true)
+	requireMax("region", a.Region, 100, false)
+	requireMax("postal_code", a.PostalCode, 20, false)
+	if !countryPattern.MatchString(a.Country) {
+		v.Add("country", "must be an ISO 3166-1 alpha-2 code")
+	}
+	if a.Phone != "" && !phonePattern.MatchString(a.Phone) {
+		v.Add("phone", "must be a valid phone number")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
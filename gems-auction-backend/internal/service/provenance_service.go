@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+)
+
+type ProvenanceService struct {
+	gemRepo  *repository.GemRepository
+	provRepo *repository.ProvenanceRepository
+}
+
+func NewProvenanceService(gemRepo *repository.GemRepository, provRepo *repository.ProvenanceRepository) *ProvenanceService {
+	return &ProvenanceService{gemRepo: gemRepo, provRepo: provRepo}
+}
+
+type AddProvenanceRequest struct {
+	EventType   domain.ProvenanceEventType `json:"event_type"`
+	PartyName   string                     `json:"party_name"`
+	Location    string                     `json:"location"`
+	Description string                     `json:"description"`
+	OccurredAt  *time.Time                 `json:"occurred_at"`
+}
+
+func (s *ProvenanceService) List(gemID int64) ([]domain.ProvenanceEntry, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+	return s.provRepo.GetByGem(gemID)
+}
+
+// Add appends an owner-supplied claim (origin, cutter, previous owner or a
+// note). Platform sales are recorded only by auction settlement.
+func (s *ProvenanceService) Add(gemID, actorID int64, isAdmin bool, req AddProvenanceRequest) (*domain.ProvenanceEntry, error) {
+	if gemID <= 0 {
+		return nil, errors.New("invalid gem id")
+	}
+
+	g, err := s.gemRepo.GetByID(gemID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && g.SellerID != actorID {
+		return nil, ErrGemNotOwner
+	}
+
+	eventType := domain.ProvenanceEventType(strings.ToUpper(strings.TrimSpace(string(req.EventType))))
+	switch eventType {
+	case domain.ProvenanceOriginClaim, domain.ProvenanceCutter, domain.ProvenancePreviousOwner, domain.ProvenanceNote:
+	case domain.ProvenancePlatformSale:
+		return nil, errors.New("platform sales are recorded automatically")
+	default:
+		return nil, errors.New("event_type must be ORIGIN_CLAIM, CUTTER, PREVIOUS_OWNER or NOTE")
+	}
+
+	p := &domain.ProvenanceEntry{
+		GemID:       gemID,
+		EventType:   eventType,
+		PartyName:   strings.TrimSpace(req.PartyName),
+		Location:    strings.TrimSpace(req.Location),
+		Description: strings.TrimSpace(req.Description),
+		OccurredAt:  req.OccurredAt,
+		RecordedBy:  &actorID,
+	}
+
+	if p.PartyName == "" && p.Location == "" && p.Description == "" {
+		return nil, errors.New("party_name, location or description required")
+	}
+	if p.OccurredAt != nil && p.OccurredAt.After(time.Now()) {
+		return nil, errors.New("occurred_at cannot be in the future")
+	}
+
+	if err := s.provRepo.Create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
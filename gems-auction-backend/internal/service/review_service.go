@@ -0,0 +1,340 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrReviewNotParty  = errors.New("only the buyer and seller of an auction can review it")
+	ErrSaleNotSettled  = errors.New("reviews open once the seller has confirmed the auction's payment")
+	ErrAlreadyReviewed = errors.New("you have already reviewed this auction")
+	ErrNotReviewee     = errors.New("only the reviewed user can dispute a review")
+	ErrDisputeOpen     = errors.New("this review already has an open dispute")
+)
+
+const (
+	maxReviewTextLength   = 2000
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+)
+
+type ReviewService struct {
+	reviewRepo *repository.ReviewRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewReviewService(reviewRepo *repository.ReviewRepository, userRepo *repository.UserRepository) *ReviewService {
+	return &ReviewService{reviewRepo: reviewRepo, userRepo: userRepo}
+}
+
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+type ListReviewsRequest struct {
+	Role   string `form:"role"`
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+type ReviewPage struct {
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+	Items  []domain.Review `json:"items"`
+}
+
+// UserReviews is a user's public reputation: both ratings and one page of
+// the visible reviews they received.
+type UserReviews struct {
+	UserID       int64                `json:"user_id"`
+	FullName     string               `json:"full_name"`
+	SellerRating domain.RatingSummary `json:"seller_rating"`
+	BuyerRating  domain.RatingSummary `json:"buyer_rating"`
+	ReviewPage
+}
+
+type ModerateReviewRequest struct {
+	Status domain.ReviewStatus `json:"status"`
+	Note   string              `json:"note"`
+}
+
+type DisputeReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ResolveDisputeRequest struct {
+	Status   domain.DisputeStatus `json:"status"`
+	Response string               `json:"response"`
+}
+
+// Create lets the winner review the seller, or the seller the winner, once
+// the winner's payment has completed. Each party reviews an auction once.
+func (s *ReviewService) Create(auctionID, userID int64, req CreateReviewRequest) (*domain.Review, error) {
+	if auctionID <= 0 {
+		return nil, errors.New("invalid auction id")
+	}
+
+	var v ValidationError
+	if req.Rating < 1 || req.Rating > 5 {
+		v.Add("rating", "must be between 1 and 5")
+	}
+	comment := strings.TrimSpace(req.Comment)
+	switch {
+	case comment == "":
+		v.Add("comment", "required")
+	case utf8.RuneCountInString(comment) > maxReviewTextLength:
+		v.Add("comment", "must be at most 2000 characters")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	sale, err := s.reviewRepo.GetAuctionSale(auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &domain.Review{
+		AuctionID:  auctionID,
+		ReviewerID: userID,
+		Rating:     req.Rating,
+		Comment:    comment,
+		Status:     domain.ReviewVisible,
+		CreatedAt:  time.Now(),
+	}
+	switch {
+	case sale.WinnerID != nil && *sale.WinnerID == userID:
+		rv.RevieweeID = sale.SellerID
+		rv.RevieweeRole = domain.ReviewOfSeller
+	case sale.SellerID == userID:
+		if sale.WinnerID == nil {
+			return nil, ErrSaleNotSettled
+		}
+		rv.RevieweeID = *sale.WinnerID
+		rv.RevieweeRole = domain.ReviewOfBuyer
+	default:
+		return nil, ErrReviewNotParty
+	}
+	if sale.Status != domain.AuctionEnded || !sale.Paid {
+		return nil, ErrSaleNotSettled
+	}
+	rv.UpdatedAt = rv.CreatedAt
+
+	if err := s.reviewRepo.Create(rv); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAlreadyReviewed
+		}
+		return nil, err
+	}
+	return rv, nil
+}
+
+// ListForAuction returns the visible reviews left on an auction.
+func (s *ReviewService) ListForAuction(auctionID int64) ([]domain.Review, error) {
+	reviews, _, err := s.reviewRepo.Search(repository.ReviewFilter{
+		AuctionID: auctionID,
+		Status:    domain.ReviewVisible,
+		Limit:     2, // one per party
+	})
+	return reviews, err
+}
+
+// ListForUser returns a user's ratings and the visible reviews they
+// received, optionally only those as a seller or as a buyer.
+func (s *ReviewService) ListForUser(userID int64, req ListReviewsRequest) (*UserReviews, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := reviewFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	f.RevieweeID = userID
+	f.Status = domain.ReviewVisible
+
+	out := &UserReviews{UserID: user.ID, FullName: user.FullName}
+	if out.SellerRating, out.BuyerRating, err = s.reviewRepo.GetRatings(userID); err != nil {
+		return nil, err
+	}
+
+	items, total, err := s.reviewRepo.Search(f)
+	if err != nil {
+		return nil, err
+	}
+	out.ReviewPage = ReviewPage{Total: total, Limit: f.Limit, Offset: f.Offset, Items: items}
+	return out, nil
+}
+
+// Search is the admin view of all reviews, hidden ones included.
+func (s *ReviewService) Search(req ListReviewsRequest) (*ReviewPage, error) {
+	f, err := reviewFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch st := domain.ReviewStatus(strings.ToUpper(strings.TrimSpace(req.Status))); st {
+	case "":
+	case domain.ReviewVisible, domain.ReviewHidden:
+		f.Status = st
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	items, total, err := s.reviewRepo.Search(f)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewPage{Total: total, Limit: f.Limit, Offset: f.Offset, Items: items}, nil
+}
+
+func reviewFilter(req ListReviewsRequest) (repository.ReviewFilter, error) {
+	f := repository.ReviewFilter{Limit: req.Limit, Offset: req.Offset}
+	if f.Limit <= 0 {
+		f.Limit = defaultReviewPageSize
+	}
+	if f.Limit > maxReviewPageSize {
+		f.Limit = maxReviewPageSize
+	}
+	if f.Offset < 0 {
+		return f, errors.New("offset must be >= 0")
+	}
+
+	switch role := domain.ReviewRole(strings.ToUpper(strings.TrimSpace(req.Role))); role {
+	case "":
+	case domain.ReviewOfSeller, domain.ReviewOfBuyer:
+		f.RevieweeRole = role
+	default:
+		return f, errors.New("role must be SELLER or BUYER")
+	}
+	return f, nil
+}
+
+// Moderate hides or restores a review. Hiding needs a note explaining why.
+func (s *ReviewService) Moderate(reviewID, adminID int64, req ModerateReviewRequest) (*domain.Review, error) {
+	status := domain.ReviewStatus(strings.ToUpper(strings.TrimSpace(string(req.Status))))
+	if status != domain.ReviewVisible && status != domain.ReviewHidden {
+		return nil, errors.New("status must be VISIBLE or HIDDEN")
+	}
+	note := strings.TrimSpace(req.Note)
+	if status == domain.ReviewHidden && note == "" {
+		return nil, errors.New("note required when hiding a review")
+	}
+
+	rv, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rv.Status = status
+	rv.ModeratedBy = &adminID
+	rv.ModeratedAt = &now
+	rv.ModerationNote = note
+
+	if err := s.reviewRepo.Moderate(rv); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Dispute lets the reviewed user contest a review for an admin to answer.
+func (s *ReviewService) Dispute(reviewID, userID int64, req DisputeReviewRequest) (*domain.ReviewDispute, error) {
+	reason := strings.TrimSpace(req.Reason)
+	var v ValidationError
+	switch {
+	case reason == "":
+		v.Add("reason", "required")
+	case utf8.RuneCountInString(reason) > maxReviewTextLength:
+		v.Add("reason", "must be at most 2000 characters")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	rv, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if rv.RevieweeID != userID {
+		return nil, ErrNotReviewee
+	}
+	if rv.Status != domain.ReviewVisible {
+		return nil, errors.New("review is already hidden")
+	}
+
+	d := &domain.ReviewDispute{
+		ReviewID:  rv.ID,
+		OpenedBy:  userID,
+		Reason:    reason,
+		Status:    domain.DisputeOpen,
+		CreatedAt: time.Now(),
+	}
+	d.UpdatedAt = d.CreatedAt
+
+	if err := s.reviewRepo.CreateDispute(d); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDisputeOpen
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *ReviewService) ListDisputes(status string) ([]domain.ReviewDispute, error) {
+	st := domain.DisputeStatus(strings.ToUpper(strings.TrimSpace(status)))
+	if st == "" {
+		st = domain.DisputeOpen
+	}
+	switch st {
+	case domain.DisputeOpen, domain.DisputeUpheld, domain.DisputeDismissed:
+	default:
+		return nil, errors.New("invalid status")
+	}
+	return s.reviewRepo.GetDisputesByStatus(st)
+}
+
+// ResolveDispute answers an open dispute. Upholding it hides the review,
+// with the response recorded as the moderation note.
+func (s *ReviewService) ResolveDispute(disputeID, adminID int64, req ResolveDisputeRequest) (*domain.ReviewDispute, error) {
+	status := domain.DisputeStatus(strings.ToUpper(strings.TrimSpace(string(req.Status))))
+	if status != domain.DisputeUpheld && status != domain.DisputeDismissed {
+		return nil, errors.New("status must be UPHELD or DISMISSED")
+	}
+	response := strings.TrimSpace(req.Response)
+	if response == "" {
+		return nil, errors.New("response required")
+	}
+
+	d, err := s.reviewRepo.GetDispute(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != domain.DisputeOpen {
+		return nil, errors.New("dispute has already been resolved")
+	}
+
+	now := time.Now()
+	d.Status = status
+	d.ResolvedBy = &adminID
+	d.ResolvedAt = &now
+	d.Response = response
+
+	if err := s.reviewRepo.ResolveDispute(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+)
+
+var (
+	ErrAdminRequired      = errors.New("only admins can grant or revoke the ADMIN role")
+	ErrAdminRoleProtected = errors.New("only admins can change the ADMIN role")
+)
+
+type RoleService struct {
+	userRepo *repository.UserRepository
+	roleRepo *repository.RoleRepository
+}
+
+func NewRoleService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) *RoleService {
+	return &RoleService{userRepo: userRepo, roleRepo: roleRepo}
+}
+
+type SetUserRolesRequest struct {
+	Roles []domain.UserRole `json:"roles"`
+}
+
+type SetRequire2FARequest struct {
+	Required bool `json:"required"`
+}
+
+func (s *RoleService) List() ([]domain.Role, error) {
+	return s.roleRepo.List()
+}
+
+// SetRequire2FA makes two-factor authentication mandatory (or optional) for
+// every holder of the role. Members who have not enrolled are asked to at
+// their next login or refresh. Only admins may change the ADMIN role.
+func (s *RoleService) SetRequire2FA(role domain.UserRole, actorIsAdmin bool, req SetRequire2FARequest) error {
+	role = domain.UserRole(strings.ToUpper(strings.TrimSpace(string(role))))
+	if role == domain.RoleAdmin && !actorIsAdmin {
+		return ErrAdminRoleProtected
+	}
+	return s.roleRepo.SetRequire2FA(role, req.Required)
+}
+
+// SetUserRoles replaces the roles of a user. The user's current access
+// tokens are revoked, so the change applies from their next refresh. Granting or revoking ADMIN requires the actor
+// to be an admin.
+func (s *RoleService) SetUserRoles(userID, actorID int64, actorIsAdmin bool, req SetUserRolesRequest) (*domain.User, error) {
+	if userID <= 0 {
+		return nil, errors.New("invalid user id")
+	}
+
+	roles, err := normalizeRoles(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, errors.New("at least one role required")
+	}
+
+	current, _, err := s.roleRepo.GetUserAccess(userID)
+	if err != nil {
+		return nil, err
+	}
+	if containsRole(roles, domain.RoleAdmin) != containsRole(current, domain.RoleAdmin) {
+		if !actorIsAdmin {
+			return nil, ErrAdminRequired
+		}
+		// an admin cannot lock themselves out
+		if userID == actorID {
+			return nil, errors.New("you cannot remove your own ADMIN role")
+		}
+	}
+
+	if err := s.roleRepo.SetUserRoles(userID, actorID, roles); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	user.Roles, user.Permissions, err = s.roleRepo.GetUserAccess(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// normalizeRoles upper-cases and de-duplicates role names, rejecting unknown
+// ones.
+func normalizeRoles(in []domain.UserRole) ([]domain.UserRole, error) {
+	var roles []domain.UserRole
+	for _, r := range in {
+		role := domain.UserRole(strings.ToUpper(strings.TrimSpace(string(r))))
+		if role == "" || containsRole(roles, role) {
+			continue
+		}
+		switch role {
+		case domain.RoleAdmin, domain.RoleSeller, domain.RoleBuyer:
+		default:
+			return nil, errors.New("unknown role: " + string(role))
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func containsRole(roles []domain.UserRole, role domain.UserRole) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
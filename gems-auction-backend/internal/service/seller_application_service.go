@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/boswin/gems-auction-backend/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrApplicationPending = errors.New("a seller application is already pending review")
+	ErrAlreadySeller      = errors.New("account already has the SELLER role")
+)
+
+const maxSellerDocuments = 10
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{5,18}[0-9]$`)
+
+type SellerApplicationService struct {
+	appRepo  *repository.SellerApplicationRepository
+	roleRepo *repository.RoleRepository
+	store    storage.BlobStore
+	maxBytes int64
+}
+
+// NewSellerApplicationService takes a private BlobStore: application
+// documents must not be reachable through the public media route.
+func NewSellerApplicationService(
+	appRepo *repository.SellerApplicationRepository,
+	roleRepo *repository.RoleRepository,
+	store storage.BlobStore,
+	maxBytes int64,
+) *SellerApplicationService {
+	return &SellerApplicationService{appRepo: appRepo, roleRepo: roleRepo, store: store, maxBytes: maxBytes}
+}
+
+// SellerApplicationRequest is submitted as multipart/form-data. The i-th
+// entry of DocumentTypes describes the i-th uploaded document.
+type SellerApplicationRequest struct {
+	BusinessName       string   `form:"business_name"`
+	BusinessType       string   `form:"business_type"`
+	RegistrationNumber string   `form:"registration_number"`
+	TaxID              string   `form:"tax_id"`
+	Phone              string   `form:"phone"`
+	Address            string   `form:"address"`
+	Website            string   `form:"website"`
+	DocumentTypes      []string `form:"document_types"`
+}
+
+type ReviewSellerApplicationRequest struct {
+	Status domain.SellerApplicationStatus `json:"status"`
+	Note   string                         `json:"note"`
+}
+
+// MaxUploadBytes is the most the documents of one application may add up
+// to.
+func (s *SellerApplicationService) MaxUploadBytes() int64 {
+	return maxSellerDocuments * s.maxBytes
+}
+
+// Apply submits a seller application with its supporting documents.
+func (s *SellerApplicationService) Apply(userID int64, req SellerApplicationRequest, files []*multipart.FileHeader) (*domain.SellerApplication, error) {
+	roles, _, err := s.roleRepo.GetUserAccess(userID)
+	if err != nil {
+		return nil, err
+	}
+	if containsRole(roles, domain.RoleSeller) {
+		return nil, ErrAlreadySeller
+	}
+
+	a := &domain.SellerApplication{
+		UserID:             userID,
+		BusinessName:       strings.TrimSpace(req.BusinessName),
+		BusinessType:       strings.ToUpper(strings.TrimSpace(req.BusinessType)),
+		RegistrationNumber: strings.TrimSpace(req.RegistrationNumber),
+		TaxID:              strings.TrimSpace(req.TaxID),
+		Phone:              strings.TrimSpace(req.Phone),
+		Address:            strings.TrimSpace(req.Address),
+		Website:            strings.TrimSpace(req.Website),
+		Status:             domain.SellerApplicationPending,
+	}
+
+	var v ValidationError
+	if a.BusinessName == "" {
+		v.Add("business_name", "required")
+	} else if len(a.BusinessName) > 255 {
+		v.Add("business_name", "must be at most 255 characters")
+	}
+	if !containsString(domain.SellerBusinessTypes, a.BusinessType) {
+		v.Add("business_type", "must be one of "+strings.Join(domain.SellerBusinessTypes, ", "))
+	}
+	if a.BusinessType != "INDIVIDUAL" && a.RegistrationNumber == "" {
+		v.Add("registration_number", "required for registered businesses")
+	}
+	if !phonePattern.MatchString(a.Phone) {
+		v.Add("phone", "must be a valid phone number")
+	}
+	if a.Address == "" {
+		v.Add("address", "required")
+	}
+	if a.Website != "" {
+		if u, err := url.Parse(a.Website); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.Add("website", "must be an http(s) URL")
+		}
+	}
+
+	switch {
+	case len(files) == 0:
+		v.Add("documents", "at least one document required")
+	case len(files) > maxSellerDocuments:
+		v.Add("documents", fmt.Sprintf("at most %d documents", maxSellerDocuments))
+	}
+	docTypes := make([]string, len(files))
+	for i := range files {
+		docTypes[i] = "OTHER"
+		if i < len(req.DocumentTypes) && strings.TrimSpace(req.DocumentTypes[i]) != "" {
+			docTypes[i] = strings.ToUpper(strings.TrimSpace(req.DocumentTypes[i]))
+		}
+		if !containsString(domain.SellerDocumentTypes, docTypes[i]) {
+			v.Add("document_types", "must be one of "+strings.Join(domain.SellerDocumentTypes, ", "))
+		}
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	for i, fh := range files {
+		doc, err := s.storeDocument(userID, fh)
+		if err != nil {
+			s.deleteDocuments(ctx, a.Documents)
+			return nil, err
+		}
+		doc.DocType = docTypes[i]
+		a.Documents = append(a.Documents, *doc)
+	}
+
+	if err := s.appRepo.Create(a); err != nil {
+		s.deleteDocuments(ctx, a.Documents)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrApplicationPending
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetMine returns the user's latest application.
+func (s *SellerApplicationService) GetMine(userID int64) (*domain.SellerApplication, error) {
+	return s.appRepo.GetLatestByUser(userID)
+}
+
+// ListForReview is the admin queue; status defaults to PENDING.
+func (s *SellerApplicationService) ListForReview(status string) ([]domain.SellerApplication, error) {
+	st := domain.SellerApplicationStatus(strings.ToUpper(strings.TrimSpace(status)))
+	if st == "" {
+		st = domain.SellerApplicationPending
+	}
+	if !validSellerApplicationStatus(st) {
+		return nil, errors.New("invalid status")
+	}
+	return s.appRepo.GetByStatus(st)
+}
+
+// Review approves or rejects a pending application. Approval grants the
+// applicant the SELLER role; it takes effect at their next token refresh.
+func (s *SellerApplicationService) Review(appID, adminID int64, req ReviewSellerApplicationRequest) (*domain.SellerApplication, error) {
+	if appID <= 0 {
+		return nil, errors.New("invalid application id")
+	}
+
+	status := domain.SellerApplicationStatus(strings.ToUpper(strings.TrimSpace(string(req.Status))))
+	if status != domain.SellerApplicationApproved && status != domain.SellerApplicationRejected {
+		return nil, errors.New("status must be APPROVED or REJECTED")
+	}
+	note := strings.TrimSpace(req.Note)
+	if status == domain.SellerApplicationRejected && note == "" {
+		return nil, errors.New("note required when rejecting an application")
+	}
+
+	a, err := s.appRepo.GetByID(appID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != domain.SellerApplicationPending {
+		return nil, errors.New("application has already been reviewed")
+	}
+
+	now := time.Now()
+	a.Status = status
+	a.ReviewedBy = &adminID
+	a.ReviewedAt = &now
+	a.ReviewNote = note
+
+	if err := s.appRepo.Review(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// OpenDocument returns a document of an application and its contents. The
+// caller must close the reader.
+func (s *SellerApplicationService) OpenDocument(appID, docID int64) (*domain.SellerDocument, io.ReadCloser, error) {
+	doc, err := s.appRepo.GetDocument(appID, docID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err := s.store.Open(context.Background(), doc.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, rc, nil
+}
+
+func (s *SellerApplicationService) storeDocument(userID int64, fh *multipart.FileHeader) (*domain.SellerDocument, error) {
+	stored, err := storeDocument(s.store, s.maxBytes, fmt.Sprintf("seller-applications/%d", userID), fh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SellerDocument{
+		FileName:    stored.FileName,
+		Key:         stored.Key,
+		ContentType: stored.ContentType,
+		SizeBytes:   stored.SizeBytes,
+	}, nil
+}
+
+func (s *SellerApplicationService) deleteDocuments(ctx context.Context, docs []domain.SellerDocument) {
+	for _, d := range docs {
+		_ = s.store.Delete(ctx, d.Key)
+	}
+}
+
+func validSellerApplicationStatus(st domain.SellerApplicationStatus) bool {
+	switch st {
+	case domain.SellerApplicationPending, domain.SellerApplicationApproved, domain.SellerApplicationRejected:
+		return true
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/config"
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/boswin/gems-auction-backend/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for your role")
+)
+
+const (
+	recoveryCodeCount = 10
+	// accept codes from one step either side of the server clock
+	totpSkew = 1
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	tfRepo   *repository.TwoFactorRepository
+	roleRepo *repository.RoleRepository
+	userRepo *repository.UserRepository
+}
+
+func NewTwoFactorService(
+	tfRepo *repository.TwoFactorRepository,
+	roleRepo *repository.RoleRepository,
+	userRepo *repository.UserRepository,
+) *TwoFactorService {
+	return &TwoFactorService{tfRepo: tfRepo, roleRepo: roleRepo, userRepo: userRepo}
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorSetup is returned when enrolment starts. The secret is shown so
+// users can type it in when they cannot scan the QR code.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func (s *TwoFactorService) Status(userID int64) (*domain.TwoFactorStatus, error) {
+	st, err := s.tfRepo.GetState(userID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.roleRepo.RequiresTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &domain.TwoFactorStatus{Enabled: st.Enabled(), EnabledAt: st.EnabledAt, Required: required}
+	if st.Enabled() {
+		if status.RecoveryCodesRemaining, err = s.tfRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup starts enrolment with a fresh secret. Calling it again before
+// Enable replaces the secret.
+func (s *TwoFactorService) Setup(userID int64) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := utils.EncryptString(config.AppConfig.TOTPEncryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tfRepo.SetPendingSecret(userID, enc); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.AppConfig.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms enrolment with a code from the authenticator app and
+// returns one-time recovery codes. They are only ever shown here.
+func (s *TwoFactorService) Enable(userID int64, code string) ([]string, error) {
+	st, err := s.tfRepo.GetState(userID)
+	if err != nil {
+		return nil, err
+	}
+	if st.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if st.SecretEnc == "" {
+		return nil, errors.New("start two-factor setup first")
+	}
+
+	secret, err := utils.DecryptString(config.AppConfig.TOTPEncryptionKey, st.SecretEnc)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.VerifyTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tfRepo.Enable(userID, step, hashes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns 2FA off after re-checking the password and a second factor.
+// It is refused while one of the user's roles requires 2FA.
+func (s *TwoFactorService) Disable(userID int64, req DisableTwoFactorRequest) error {
+	required, err := s.roleRepo.RequiresTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := comparePassword(user.Password, req.Password); err != nil {
+		return errors.New("invalid password")
+	}
+	if err := s.Verify(userID, req.Code); err != nil {
+		return err
+	}
+
+	return s.tfRepo.Disable(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current TOTP code.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int64, code string) ([]string, error) {
+	if err := s.verifyTOTP(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tfRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code.
+func (s *TwoFactorService) Verify(userID int64, code string) error {
+	if len(strings.TrimSpace(code)) > utils.TOTPDigits {
+		ok, err := s.tfRepo.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	return s.verifyTOTP(userID, code)
+}
+
+// IsEnabled reports whether the user has completed enrolment, and Required
+// whether one of their roles demands it.
+func (s *TwoFactorService) IsEnabled(userID int64) (enabled, required bool, err error) {
+	st, err := s.tfRepo.GetState(userID)
+	if err != nil {
+		return false, false, err
+	}
+	required, err = s.roleRepo.RequiresTwoFactor(userID)
+	return st.Enabled(), required, err
+}
+
+func (s *TwoFactorService) verifyTOTP(userID int64, code string) error {
+	st, err := s.tfRepo.GetState(userID)
+	if err != nil {
+		return err
+	}
+	if !st.Enabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	secret, err := utils.DecryptString(config.AppConfig.TOTPEncryptionKey, st.SecretEnc)
+	if err != nil {
+		return err
+	}
+	step, ok := utils.VerifyTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// each code works once
+	fresh, err := s.tfRepo.UseStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns codes formatted for display ("abcde-fghij") and
+// the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
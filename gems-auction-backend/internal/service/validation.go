@@ -0,0 +1,99 @@
+package service
+
+import (
+	"net/mail"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ValidationError collects field-level problems with a request so clients
+// can show each message next to its input.
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// Add records msg for field, keeping the first message per field.
+func (e *ValidationError) Add(field, msg string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = msg
+	}
+}
+
+// Err returns e when any field failed, nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for f := range e.Fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, f := range names {
+		parts[i] = f + ": " + e.Fields[f]
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+const (
+	minPasswordLength = 10
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+)
+
+// normalizeEmail trims and lower-cases an address; validateEmail then checks
+// it is a bare address such as "jane@example.com".
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateEmail(v *ValidationError, field, email string) {
+	if email == "" {
+		v.Add(field, "required")
+		return
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		v.Add(field, "must be a valid email address")
+	}
+}
+
+// validatePassword requires a minimum length and a mix of letters and
+// digits, and rejects passwords that contain the email's local part.
+func validatePassword(v *ValidationError, field, password, email string) {
+	if len(password) < minPasswordLength {
+		v.Add(field, "must be at least 10 characters")
+		return
+	}
+	if len(password) > maxPasswordLength {
+		v.Add(field, "must be at most 72 bytes")
+		return
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		v.Add(field, "must contain letters and digits")
+		return
+	}
+
+	if at := strings.Index(email, "@"); at >= 3 && strings.Contains(strings.ToLower(password), email[:at]) {
+		v.Add(field, "must not contain your email address")
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+	"github.com/boswin/gems-auction-backend/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+var vocabularyCodePattern = regexp.MustCompile(`^[A-Z0-9_]{1,50}$`)
+
+type VocabularyService struct {
+	vocabRepo *repository.VocabularyRepository
+}
+
+func NewVocabularyService(vocabRepo *repository.VocabularyRepository) *VocabularyService {
+	return &VocabularyService{vocabRepo: vocabRepo}
+}
+
+type CreateVocabularyRequest struct {
+	Kind       domain.VocabularyKind `json:"kind"`
+	Code       string                `json:"code"`
+	Label      string                `json:"label"`
+	ParentCode string                `json:"parent_code"`
+	SortOrder  int                   `json:"sort_order"`
+}
+
+type UpdateVocabularyRequest struct {
+	Label      *string `json:"label"`
+	ParentCode *string `json:"parent_code"`
+	SortOrder  *int    `json:"sort_order"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+func (s *VocabularyService) List(kind string, includeInactive bool) ([]domain.VocabularyTerm, error) {
+	k := domain.VocabularyKind(strings.ToUpper(strings.TrimSpace(kind)))
+	if k != "" && !validVocabularyKind(k) {
+		return nil, errors.New("invalid kind")
+	}
+	return s.vocabRepo.List(k, includeInactive)
+}
+
+func (s *VocabularyService) Create(req CreateVocabularyRequest) (*domain.VocabularyTerm, error) {
+	req.Kind = domain.VocabularyKind(strings.ToUpper(strings.TrimSpace(string(req.Kind))))
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Label = strings.TrimSpace(req.Label)
+	req.ParentCode = strings.ToUpper(strings.TrimSpace(req.ParentCode))
+
+	if !validVocabularyKind(req.Kind) {
+		return nil, errors.New("invalid kind")
+	}
+	if !vocabularyCodePattern.MatchString(req.Code) {
+		return nil, errors.New("code must be upper case letters, digits or underscores")
+	}
+	if req.Label == "" {
+		return nil, errors.New("label required")
+	}
+	if err := s.checkParent(req.Kind, req.ParentCode); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.vocabRepo.GetByCode(req.Kind, req.Code); err == nil {
+		return nil, errors.New("code already exists for this kind")
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	t := &domain.VocabularyTerm{
+		Kind:       req.Kind,
+		Code:       req.Code,
+		Label:      req.Label,
+		ParentCode: req.ParentCode,
+		SortOrder:  req.SortOrder,
+		IsActive:   true,
+	}
+
+	if err := s.vocabRepo.Create(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Update changes a term's label, parent, order or active flag. Codes are
+// immutable because gems reference them.
+func (s *VocabularyService) Update(id int64, req UpdateVocabularyRequest) (*domain.VocabularyTerm, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid vocabulary id")
+	}
+
+	t, err := s.vocabRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		label := strings.TrimSpace(*req.Label)
+		if label == "" {
+			return nil, errors.New("label required")
+		}
+		t.Label = label
+	}
+	if req.ParentCode != nil {
+		parent := strings.ToUpper(strings.TrimSpace(*req.ParentCode))
+		if err := s.checkParent(t.Kind, parent); err != nil {
+			return nil, err
+		}
+		t.ParentCode = parent
+	}
+	if req.SortOrder != nil {
+		t.SortOrder = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+
+	if err := s.vocabRepo.Update(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NormalizeAttributes upper-cases the attribute codes and checks each one
+// against the active vocabulary. A variety fills in its species when the
+// species is missing and must agree with it otherwise. When editing a gem,
+// current holds its attributes before the edit: codes it already had are
+// kept even if their term has been deactivated since, so only the fields
+// the edit changes are checked. current is nil for a new gem.
+func (s *VocabularyService) NormalizeAttributes(a *domain.GemAttributes, current *domain.GemAttributes) error {
+	var prev domain.GemAttributes
+	if current != nil {
+		prev = *current
+	}
+	kept := func(code, old string) bool {
+		return current != nil && code == strings.ToUpper(strings.TrimSpace(old))
+	}
+
+	fields := []struct {
+		name  string
+		kind  domain.VocabularyKind
+		value *string
+		old   string
+	}{
+		{"variety", domain.VocabVariety, &a.Variety, prev.Variety},
+		{"species", domain.VocabSpecies, &a.Species, prev.Species},
+		{"treatment", domain.VocabTreatment, &a.Treatment, prev.Treatment},
+		{"cut", domain.VocabCut, &a.Cut, prev.Cut},
+		{"shape", domain.VocabShape, &a.Shape, prev.Shape},
+		{"color_hue", domain.VocabColorHue, &a.ColorHue, prev.ColorHue},
+		{"color_tone", domain.VocabColorTone, &a.ColorTone, prev.ColorTone},
+		{"color_saturation", domain.VocabColorSaturation, &a.ColorSaturation, prev.ColorSaturation},
+	}
+
+	for _, f := range fields {
+		code := strings.ToUpper(strings.TrimSpace(*f.value))
+		*f.value = code
+		if code == "" {
+			continue
+		}
+
+		species := strings.ToUpper(strings.TrimSpace(a.Species))
+		if kept(code, f.old) && (f.kind != domain.VocabVariety || kept(species, prev.Species)) {
+			continue
+		}
+
+		t, err := s.vocabRepo.GetByCode(f.kind, code)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && !t.IsActive && !kept(code, f.old)) {
+			return fmt.Errorf("unknown %s %q", f.name, code)
+		}
+		if err != nil {
+			return err
+		}
+
+		if f.kind == domain.VocabVariety && t.ParentCode != "" {
+			if species == "" {
+				a.Species = t.ParentCode
+			} else if species != t.ParentCode {
+				return fmt.Errorf("variety %s belongs to species %s", code, t.ParentCode)
+			}
+		}
+	}
+
+	dims := []struct {
+		name  string
+		value *float64
+	}{
+		{"length_mm", a.LengthMM},
+		{"width_mm", a.WidthMM},
+		{"depth_mm", a.DepthMM},
+	}
+	for _, d := range dims {
+		if d.value != nil && *d.value <= 0 {
+			return fmt.Errorf("%s must be > 0", d.name)
+		}
+	}
+
+	return nil
+}
+
+// checkParent enforces that varieties point at an existing species and that
+// other kinds have no parent.
+func (s *VocabularyService) checkParent(kind domain.VocabularyKind, parent string) error {
+	if kind != domain.VocabVariety {
+		if parent != "" {
+			return errors.New("parent_code is only allowed for VARIETY")
+		}
+		return nil
+	}
+
+	if parent == "" {
+		return errors.New("parent_code (species) required for VARIETY")
+	}
+	if _, err := s.vocabRepo.GetByCode(domain.VocabSpecies, parent); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("unknown species %q", parent)
+		}
+		return err
+	}
+	return nil
+}
+
+func validVocabularyKind(kind domain.VocabularyKind) bool {
+	for _, k := range domain.VocabularyKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore persists uploaded files such as gem images.
+// Keys are slash separated relative paths, e.g. "gems/12/ab34_web.jpg".
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// Open reads a blob back, for files that are not publicly served.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// URL returns the public URL a client can fetch the blob from.
+	URL(key string) string
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+)
+
+// ResizeToFit scales img down so it fits within maxW x maxH, keeping the
+// aspect ratio. Transparent areas are flattened onto white so the result
+// can be encoded as JPEG. Images that already fit are only flattened.
+// Large images are read a strip of rows at a time, so no full size copy
+// of img is made.
+func ResizeToFit(img image.Image, maxW, maxH int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w <= maxW && h <= maxH {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		flatten(dst, dst.Bounds(), img, b.Min)
+		return dst
+	}
+
+	scale := math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	dw := max(1, int(float64(w)*scale+0.5))
+	dh := max(1, int(float64(h)*scale+0.5))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	strip := image.NewRGBA(image.Rect(0, 0, w, (h+dh-1)/dh+1))
+
+	// box filter: each destination pixel averages the source pixels it covers
+	for y := 0; y < dh; y++ {
+		sy0 := y * h / dh
+		sy1 := max(sy0+1, (y+1)*h/dh)
+		flatten(strip, image.Rect(0, 0, w, sy1-sy0), img, image.Pt(b.Min.X, b.Min.Y+sy0))
+
+		for x := 0; x < dw; x++ {
+			sx0 := x * w / dw
+			sx1 := max(sx0+1, (x+1)*w/dw)
+
+			var r, g, bl, a, n uint32
+			for sy := 0; sy < sy1-sy0; sy++ {
+				off := sy*strip.Stride + sx0*4
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(strip.Pix[off])
+					g += uint32(strip.Pix[off+1])
+					bl += uint32(strip.Pix[off+2])
+					a += uint32(strip.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+
+			off := y*dst.Stride + x*4
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(bl / n)
+			dst.Pix[off+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// flatten draws the part of img starting at sp over white into r of dst.
+func flatten(dst *image.RGBA, r image.Rectangle, img image.Image, sp image.Point) {
+	draw.Draw(dst, r, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, r, img, sp, draw.Over)
+}
+
+// EncodeJPEG encodes img as a JPEG at the given quality (1-100).
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
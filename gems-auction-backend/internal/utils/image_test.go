@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResizeToFit(t *testing.T) {
+	// left half red, right half transparent
+	src := image.NewNRGBA(image.Rect(10, 10, 1010, 510))
+	for y := 10; y < 510; y++ {
+		for x := 10; x < 510; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	dst := ResizeToFit(src, 100, 100)
+	if got := dst.Bounds(); got != image.Rect(0, 0, 100, 50) {
+		t.Fatalf("bounds = %v, want 100x50", got)
+	}
+	if got := dst.RGBAAt(10, 25); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("red half = %v", got)
+	}
+	if got := dst.RGBAAt(90, 25); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("transparent half = %v, want white", got)
+	}
+
+	small := ResizeToFit(src, 2000, 2000)
+	if got := small.Bounds(); got != image.Rect(0, 0, 1000, 500) {
+		t.Fatalf("bounds = %v, want 1000x500", got)
+	}
+	if got := small.RGBAAt(999, 499); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("transparent corner = %v, want white", got)
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B, SHA1. The RFC lists 8-digit codes; ours are their
+// last 6 digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("T=%d: code = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestTOTPCodeAcceptsLowercaseSecret(t *testing.T) {
+	got, err := TOTPCode(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil || got != "287082" {
+		t.Fatalf("code = %q, %v; want 287082", got, err)
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Fatal("expected an error for an invalid secret")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		at := time.Unix(v.unix, 0)
+		step, ok := VerifyTOTP(rfc6238Secret, v.code, at, 0)
+		if !ok || step != TOTPStep(at) {
+			t.Errorf("T=%d: VerifyTOTP = %d, %v; want %d, true", v.unix, step, ok, TOTPStep(at))
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	// 1111111109 and 1111111111 fall in consecutive steps
+	code := "081804"
+	issued := TOTPStep(time.Unix(1111111109, 0))
+	period := int64(TOTPPeriod / time.Second)
+
+	tests := []struct {
+		name string
+		unix int64
+		skew int
+		ok   bool
+	}{
+		{"same step", 1111111109, 0, true},
+		{"first second of next step, no skew", 1111111110, 0, false},
+		{"first second of next step", 1111111110, 1, true},
+		{"last second of next step", (issued+2)*period - 1, 1, true},
+		{"two steps later", (issued + 2) * period, 1, false},
+		{"two steps later, skew 2", (issued + 2) * period, 2, true},
+		{"last second of previous step", issued*period - 1, 1, true},
+		{"two steps earlier", issued*period - period - 1, 1, false},
+	}
+	for _, tt := range tests {
+		step, ok := VerifyTOTP(rfc6238Secret, code, time.Unix(tt.unix, 0), tt.skew)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if ok && step != issued {
+			t.Errorf("%s: step = %d, want %d", tt.name, step, issued)
+		}
+	}
+}
+
+func TestVerifyTOTPRejectsMalformedCodes(t *testing.T) {
+	at := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := VerifyTOTP(rfc6238Secret, code, at, 1); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+	if _, ok := VerifyTOTP(rfc6238Secret, " 287 082 ", at, 0); !ok {
+		t.Error("code with spaces rejected")
+	}
+}
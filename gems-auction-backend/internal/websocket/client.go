@@ -12,6 +12,10 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 1024 * 8 // 8 KB
+
+	// closeTokenExpired is sent when the access token of the connection
+	// expires; the client should reconnect with a fresh token.
+	closeTokenExpired = 4001
 )
 
 // Client represents one WebSocket connection
@@ -19,15 +23,22 @@ type Client struct {
 	room      *Room
 	conn      *websocket.Conn
 	send      chan []byte
-	userID    int64
+	session   Session
 	auctionID int64
+	commands  CommandHandler   // can be nil
+	snapshots SnapshotProvider // can be nil
+
+	// resumeFrom is the last seq of resumeEpoch the client is up to date
+	// with; the room replays the events after it when the client registers.
+	resumeEpoch string
+	resumeFrom  *uint64
 }
 
-// readPump reads messages from client (optional usage)
-// For now, we mainly broadcast server -> clients (bids/chat updates).
+// readPump reads commands from the client and answers each one.
 func (c *Client) readPump() {
 	defer func() {
-		c.room.unregister <- c
+		c.room.leave(c)
+		c.room.manager.releaseRoom(c.room)
 		_ = c.conn.Close()
 	}()
 
@@ -39,11 +50,11 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		// We ignore client messages for now (frontend can still send pings etc.)
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			break
 		}
+		c.handleMessage(data)
 	}
 }
 
@@ -55,6 +66,13 @@ func (c *Client) writePump() {
 		_ = c.conn.Close()
 	}()
 
+	var expired <-chan time.Time
+	if !c.session.ExpiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(c.session.ExpiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
 	for {
 		select {
 		case message, ok := <-c.send:
@@ -68,6 +86,12 @@ func (c *Client) writePump() {
 				return
 			}
 
+		case <-expired:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(closeTokenExpired, "token expired"),
+				time.Now().Add(writeWait))
+			return
+
 		case <-ticker.C:
 			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// CommandHandler executes the commands clients send over their connection.
+// Results are sent back to the sender in an ACK.
+type CommandHandler interface {
+	PlaceBid(session Session, auctionID int64, amount float64) (any, error)
+	SendChat(session Session, auctionID int64, message string) (any, error)
+}
+
+// SnapshotProvider loads the current state of an auction for clients that
+// need to resynchronise.
+type SnapshotProvider interface {
+	AuctionSnapshot(auctionID int64) (any, error)
+}
+
+// Backend is what connections need from the rest of the application.
+type Backend interface {
+	CommandHandler
+	SnapshotProvider
+}
+
+var errInvalidPayload = errors.New("invalid payload")
+
+// handleMessage runs one client message and replies to the client.
+func (c *Client) handleMessage(data []byte) {
+	var msg ClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.reply(Reply{Type: ReplyError, Error: "invalid message"})
+		return
+	}
+
+	result, err := c.runCommand(msg)
+	if err != nil {
+		c.reply(Reply{Type: ReplyError, ID: msg.ID, Error: err.Error()})
+		return
+	}
+	c.reply(Reply{Type: ReplyAck, ID: msg.ID, Payload: result})
+}
+
+func (c *Client) runCommand(msg ClientMessage) (any, error) {
+	if msg.Type == MsgPing {
+		return nil, nil
+	}
+	if c.commands == nil {
+		return nil, errors.New("commands are not supported on this connection")
+	}
+
+	switch msg.Type {
+	case MsgPlaceBid:
+		var p struct {
+			Amount float64 `json:"amount"`
+		}
+		if err := decodePayload(msg.Payload, &p); err != nil {
+			return nil, err
+		}
+		return c.commands.PlaceBid(c.session, c.auctionID, p.Amount)
+
+	case MsgSendChat:
+		var p struct {
+			Message string `json:"message"`
+		}
+		if err := decodePayload(msg.Payload, &p); err != nil {
+			return nil, err
+		}
+		return c.commands.SendChat(c.session, c.auctionID, p.Message)
+	}
+
+	return nil, errors.New("unknown message type: " + msg.Type)
+}
+
+func decodePayload(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || json.Unmarshal(raw, v) != nil {
+		return errInvalidPayload
+	}
+	return nil
+}
+
+// reply queues r for this client only. The room delivers it, so a client
+// that has already been dropped, or whose room has shut down, is skipped
+// safely.
+func (c *Client) reply(r Reply) {
+	r.Timestamp = time.Now()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	select {
+	case c.room.direct <- directMessage{client: c, msg: b}:
+	case <-c.room.done:
+	}
+}
@@ -1,11 +1,79 @@
 package websocket
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Event is what we send to frontend via WebSocket
 type Event struct {
-	Type      string    `json:"type"`
-	AuctionID int64     `json:"auction_id"`
+	Type      string `json:"type"`
+	AuctionID int64  `json:"auction_id"`
+	// Seq numbers the events broadcast to an auction's room, without gaps.
+	// Events sent to a single client carry the seq they are current as of.
+	Seq uint64 `json:"seq"`
+	// Epoch names the run of the room that numbered the event. A room that
+	// is recreated, after it shut down or on another replica, numbers from
+	// scratch under a new epoch, so a reconnecting client sends both and
+	// resyncs when the epoch differs.
+	Epoch     string    `json:"epoch,omitempty"`
 	Payload   any       `json:"payload"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// Broadcast events the room acts on. A room shuts down, disconnecting its
+// clients, once it has delivered AUCTION_ENDED; BID_PLACED makes the bidder
+// an active bidder.
+const (
+	EventAuctionEnded = "AUCTION_ENDED"
+	EventBidPlaced    = "BID_PLACED"
+)
+
+// EventPresence goes to a room's clients when its audience changes, at most
+// every presenceInterval. It is not numbered: its seq is that of the
+// latest event, and it is not replayed.
+const EventPresence = "PRESENCE"
+
+// Events sent to a single client. A joining client gets WS_CONNECTED and
+// a SNAPSHOT of the auction; a reconnecting client whose missed events are
+// no longer buffered, or were numbered under another epoch, gets
+// RESYNC_REQUIRED first. Events after the snapshot's seq follow as usual.
+// When the pubsub listener reconnects, rooms restart their numbering under
+// a new epoch and their clients get RESYNC_REQUIRED, then a SNAPSHOT.
+const (
+	EventConnected      = "WS_CONNECTED"
+	EventResyncRequired = "RESYNC_REQUIRED"
+	EventSnapshot       = "SNAPSHOT"
+)
+
+// Message types clients send over their connection. Commands apply to the
+// auction of the connection.
+const (
+	MsgPlaceBid = "PLACE_BID" // payload {"amount": 1250}
+	MsgSendChat = "SEND_CHAT" // payload {"message": "..."}
+	MsgPing     = "PING"
+)
+
+// ClientMessage is a command sent by a client. ID is chosen by the client
+// and echoed in the reply so it can match replies to its requests.
+type ClientMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Reply types.
+const (
+	ReplyAck   = "ACK"
+	ReplyError = "ERROR"
+)
+
+// Reply answers one ClientMessage and goes to its sender only. Payload is
+// the command's result on ACK; Error explains an ERROR.
+type Reply struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Payload   any       `json:"payload,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
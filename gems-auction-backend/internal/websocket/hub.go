@@ -1,142 +1,611 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
-// Room manages clients for a single auction
+const (
+	// roomIdleTimeout is how long a room stays up without clients before
+	// it shuts down.
+	roomIdleTimeout = 2 * time.Minute
+	// roomIdleCheck is how often a room checks whether it has been idle
+	// long enough.
+	roomIdleCheck = 30 * time.Second
+)
+
+// Room manages clients for a single auction. It runs until it has been
+// idle for roomIdleTimeout or its auction ends, then closes done and
+// disconnects whoever is left.
 type Room struct {
 	auctionID  int64
+	manager    *Manager
 	clients    map[*Client]bool
 	register   chan *Client
 	unregister chan *Client
-	broadcast  chan []byte
+	broadcast  chan Event
+	direct     chan directMessage
+	kick       chan int64
+	done       chan struct{}
+	replay     replayBuffer
+	presence   *presence
+	resync     chan struct{}
+	snapshots  chan roomSnapshot
+
+	// stale holds the clients told to resync that wait for a snapshot; no
+	// events are sent to them until it arrives. loading is set while one is
+	// being loaded.
+	stale   map[*Client]bool
+	loading bool
+
+	// refs counts the connections holding the room, including those still
+	// loading their snapshot. Guarded by manager.mu.
+	refs int
+	// clientCount mirrors len(clients) for Stats.
+	clientCount atomic.Int64
+}
+
+// directMessage is sent to one client of the room only.
+type directMessage struct {
+	client *Client
+	msg    []byte
 }
 
-func newRoom(auctionID int64) *Room {
+// roomSnapshot is the auction's state, current as of seq of epoch.
+type roomSnapshot struct {
+	epoch string
+	seq   uint64
+	data  any
+	err   error
+}
+
+func newRoom(auctionID int64, manager *Manager) *Room {
 	return &Room{
 		auctionID:  auctionID,
+		manager:    manager,
 		clients:    make(map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		broadcast:  make(chan []byte, 256),
+		broadcast:  make(chan Event, 256),
+		direct:     make(chan directMessage, 64),
+		kick:       make(chan int64),
+		done:       make(chan struct{}),
+		replay:     replayBuffer{epoch: randomID()},
+		presence:   newPresence(),
+		resync:     make(chan struct{}, 1),
+		snapshots:  make(chan roomSnapshot),
+		stale:      make(map[*Client]bool),
 	}
 }
 
 func (r *Room) run() {
+	idle := time.NewTicker(roomIdleCheck)
+	defer idle.Stop()
+	presenceTick := time.NewTicker(presenceInterval)
+	defer presenceTick.Stop()
+	lastActive := time.Now()
+
 	for {
 		select {
 		case c := <-r.register:
 			r.clients[c] = true
+			r.presence.join(c.session)
+			if c.resumeFrom != nil {
+				r.resume(c, c.resumeEpoch, *c.resumeFrom)
+			}
 
 		case c := <-r.unregister:
 			if _, ok := r.clients[c]; ok {
-				delete(r.clients, c)
-				close(c.send)
+				r.remove(c)
+			}
+
+		case userID := <-r.kick:
+			for c := range r.clients {
+				if c.session.UserID == userID {
+					r.remove(c)
+				}
 			}
 
-		case msg := <-r.broadcast:
+		case dm := <-r.direct:
+			if _, ok := r.clients[dm.client]; !ok {
+				continue
+			}
+			r.send(dm.client, dm.msg)
+
+		case ev := <-r.broadcast:
+			msg, err := r.replay.add(&ev)
+			if err != nil {
+				log.Println("WS event encode error:", err)
+				continue
+			}
 			for c := range r.clients {
-				select {
-				case c.send <- msg:
-				default:
-					// if client is slow, drop it
-					delete(r.clients, c)
-					close(c.send)
+				// stale clients still learn that the auction is over
+				if !r.stale[c] || ev.Type == EventAuctionEnded {
+					r.send(c, msg)
 				}
 			}
+			if ev.Type == EventBidPlaced {
+				r.presence.bid(msg, ev.Timestamp)
+			}
+			if ev.Type == EventAuctionEnded {
+				r.manager.removeRoom(r)
+				r.shutdown()
+				return
+			}
+
+		case <-idle.C:
+			if len(r.clients) == 0 && time.Since(lastActive) >= roomIdleTimeout && r.manager.removeIfUnused(r) {
+				r.shutdown()
+				return
+			}
+
+		case <-r.resync:
+			r.replay.restart()
+			for c := range r.clients {
+				r.resyncClient(c)
+			}
+
+		case snap := <-r.snapshots:
+			r.deliverSnapshot(snap)
+
+		case <-presenceTick.C:
+			r.sendPresence()
+		}
+
+		if r.presence.changed {
+			r.presence.refresh(time.Now())
+		}
+		r.clientCount.Store(int64(len(r.clients)))
+		if len(r.clients) > 0 {
+			lastActive = time.Now()
 		}
 	}
 }
 
+// send queues msg for c. A client too slow to keep up is dropped.
+func (r *Room) send(c *Client, msg []byte) bool {
+	select {
+	case c.send <- msg:
+		r.manager.stats.messagesSent.Add(1)
+		return true
+	default:
+		r.manager.stats.droppedClients.Add(1)
+		r.remove(c)
+		return false
+	}
+}
+
+// remove drops c from the room; closing its send channel ends its
+// connection once the queued messages are written.
+func (r *Room) remove(c *Client) {
+	delete(r.clients, c)
+	delete(r.stale, c)
+	close(c.send)
+	r.presence.leave(c.session)
+}
+
+// sendPresence tells the clients the auction's presence, across all
+// instances, when it changed since the last PRESENCE. Joins and leaves in
+// between are coalesced. The local audience is shared with the other
+// instances when it changes and every presenceHeartbeat.
+func (r *Room) sendPresence() {
+	now := time.Now()
+	local := r.presence.refresh(now)
+	if !sameAudience(local, r.presence.shared) || now.Sub(r.presence.sharedAt) >= presenceHeartbeat {
+		r.manager.sharePresence(r.auctionID, local)
+		r.presence.shared, r.presence.sharedAt = local, now
+	}
+
+	cur := r.manager.remote.total(r.auctionID, local)
+	if cur == r.presence.sent {
+		return
+	}
+	r.presence.sent = cur
+
+	epoch, seq := r.replay.head()
+	msg := r.directEvent(EventPresence, epoch, seq, cur)
+	for c := range r.clients {
+		r.send(c, msg)
+	}
+}
+
+// shutdown disconnects the remaining clients. The room must already be
+// out of the manager.
+func (r *Room) shutdown() {
+	close(r.done)
+	for c := range r.clients {
+		r.remove(c)
+	}
+	r.clientCount.Store(0)
+	r.manager.sharePresence(r.auctionID, presenceReport{})
+}
+
+// join registers c, unless the room has shut down meanwhile.
+func (r *Room) join(c *Client) bool {
+	select {
+	case r.register <- c:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// leave unregisters c. A room that has shut down has already let go of it.
+func (r *Room) leave(c *Client) {
+	select {
+	case r.unregister <- c:
+	case <-r.done:
+	}
+}
+
+// publish hands ev to the room without waiting. When the room is too far
+// behind the event is dropped rather than stalling the caller.
+func (r *Room) publish(ev Event) {
+	select {
+	case r.broadcast <- ev:
+	case <-r.done:
+	default:
+		r.manager.stats.droppedEvents.Add(1)
+		log.Printf("WS room %d is backed up, dropping %s event", r.auctionID, ev.Type)
+	}
+}
+
+// resume sends a joining client the events broadcast after seq of epoch,
+// which it has not seen. If they are no longer buffered it is resynced.
+func (r *Room) resume(c *Client, epoch string, seq uint64) {
+	missed, ok := r.replay.since(epoch, seq)
+	if !ok {
+		r.resyncClient(c)
+		return
+	}
+	for _, msg := range missed {
+		if !r.send(c, msg) {
+			return
+		}
+	}
+}
+
+// resyncClient tells c to resync. Clients that can be sent a snapshot get
+// no more events until it has been loaded.
+func (r *Room) resyncClient(c *Client) {
+	epoch, seq := r.replay.head()
+	if !r.send(c, r.directEvent(EventResyncRequired, epoch, seq, nil)) || c.snapshots == nil {
+		return
+	}
+	r.stale[c] = true
+	r.loadSnapshot()
+}
+
+// loadSnapshot loads the auction's state for the stale clients in the
+// background, unless a load is already under way.
+func (r *Room) loadSnapshot() {
+	var provider SnapshotProvider
+	for c := range r.stale {
+		provider = c.snapshots
+		break
+	}
+	if r.loading || provider == nil {
+		return
+	}
+	r.loading = true
+
+	epoch, seq := r.replay.head()
+	go func() {
+		data, err := provider.AuctionSnapshot(r.auctionID)
+		select {
+		case r.snapshots <- roomSnapshot{epoch: epoch, seq: seq, data: data, err: err}:
+		case <-r.done:
+		}
+	}()
+}
+
+// deliverSnapshot sends the stale clients snap followed by the events
+// broadcast since it was loaded. If they are no longer buffered, or the
+// room restarted its numbering meanwhile, it loads another one. Without a
+// snapshot the clients are dropped so they reconnect.
+func (r *Room) deliverSnapshot(snap roomSnapshot) {
+	r.loading = false
+	if snap.err != nil {
+		log.Println("WS snapshot error:", snap.err)
+		for c := range r.stale {
+			r.remove(c)
+		}
+		return
+	}
+
+	missed, ok := r.replay.since(snap.epoch, snap.seq)
+	if !ok {
+		r.loadSnapshot()
+		return
+	}
+	msg := r.directEvent(EventSnapshot, snap.epoch, snap.seq, snap.data)
+	for c := range r.stale {
+		delete(r.stale, c)
+		if !r.send(c, msg) {
+			continue
+		}
+		for _, m := range missed {
+			if !r.send(c, m) {
+				break
+			}
+		}
+	}
+}
+
+// directEvent encodes an event for a single client that is current as of
+// seq of epoch.
+func (r *Room) directEvent(eventType, epoch string, seq uint64, payload any) []byte {
+	b, _ := json.Marshal(Event{
+		Type:      eventType,
+		AuctionID: r.auctionID,
+		Seq:       seq,
+		Epoch:     epoch,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	})
+	return b
+}
+
 // Manager controls all rooms (auction rooms)
 type Manager struct {
-	mu    sync.RWMutex
-	rooms map[int64]*Room
+	mu       sync.RWMutex
+	rooms    map[int64]*Room
+	upgrader websocket.Upgrader
+	pubsub   PubSub // nil when running a single instance
+	stats    hubCounters
+
+	// instance tells this instance's presence reports apart from the
+	// others'; remote holds theirs.
+	instance      string
+	remote        remotePresence
+	presenceShare chan Event
+}
+
+// NewManager accepts handshakes from the given browser origins. Requests
+// without an Origin header come from non-browser clients and are allowed.
+func NewManager(allowedOrigins []string) *Manager {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimRight(o, "/"))] = true
+	}
+
+	m := &Manager{
+		rooms:         make(map[int64]*Room),
+		instance:      randomID(),
+		remote:        remotePresence{byAuction: make(map[int64]map[string]presenceReport)},
+		presenceShare: make(chan Event, 256),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || allowed[strings.ToLower(origin)]
+			},
+		},
+	}
+	go m.stats.sample()
+	return m
+}
+
+// UsePubSub shares events with the other server instances through ps and
+// starts listening for theirs. Call it once, before serving connections.
+func (m *Manager) UsePubSub(ps PubSub) {
+	m.pubsub = ps
+	go m.publishPresence()
+	go func() {
+		reconnecting := false
+		for {
+			err := ps.Listen(context.Background(), func() {
+				// events published while this instance was not listening
+				// never reached its rooms
+				if reconnecting {
+					m.resyncRooms()
+				}
+			}, m.deliverRemote)
+			log.Println("WS pubsub listener stopped, reconnecting:", err)
+			reconnecting = true
+			time.Sleep(pubsubRetryDelay)
+		}
+	}()
+}
+
+// resyncRooms has every room restart its numbering and resync its
+// clients.
+func (m *Manager) resyncRooms() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, room := range m.rooms {
+		select {
+		case room.resync <- struct{}{}:
+		default: // a resync is already pending
+		}
+	}
 }
 
-func NewManager() *Manager {
-	return &Manager{
-		rooms: make(map[int64]*Room),
+// deliverRemote hands an event from another instance to the local room.
+// Auctions nobody here is watching are skipped.
+func (m *Manager) deliverRemote(ev Event) {
+	raw, _ := ev.Payload.(json.RawMessage)
+	switch ev.Type {
+	case ctrlDisconnectUser:
+		var userID int64
+		if json.Unmarshal(raw, &userID) == nil {
+			m.disconnectLocal(userID)
+		}
+		return
+
+	case ctrlPresence:
+		var rep presenceReport
+		if json.Unmarshal(raw, &rep) == nil {
+			m.remote.set(ev.AuctionID, rep)
+		}
+		return
+	}
+
+	if room := m.room(ev.AuctionID); room != nil {
+		room.publish(ev)
 	}
 }
 
-func (m *Manager) getOrCreateRoom(auctionID int64) *Room {
+// room returns the running room of the auction, or nil.
+func (m *Manager) room(auctionID int64) *Room {
 	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rooms[auctionID]
+}
+
+// acquireRoom returns the auction's room, starting it if needed, and holds
+// it open until releaseRoom.
+func (m *Manager) acquireRoom(auctionID int64) *Room {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	room, ok := m.rooms[auctionID]
-	m.mu.RUnlock()
-	if ok {
-		return room
+	if !ok {
+		room = newRoom(auctionID, m)
+		m.rooms[auctionID] = room
+		go room.run()
 	}
+	room.refs++
+	return room
+}
+
+func (m *Manager) releaseRoom(room *Room) {
+	m.mu.Lock()
+	room.refs--
+	m.mu.Unlock()
+}
 
+// removeIfUnused takes an idle room out of the manager, unless a
+// connection is about to join it.
+func (m *Manager) removeIfUnused(room *Room) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// re-check after lock
-	room, ok = m.rooms[auctionID]
-	if ok {
-		return room
+	if room.refs > 0 {
+		return false
 	}
+	delete(m.rooms, room.auctionID)
+	return true
+}
 
-	room = newRoom(auctionID)
-	m.rooms[auctionID] = room
-	go room.run()
-	return room
+// removeRoom takes a room out of the manager; the next connection to its
+// auction starts a new one.
+func (m *Manager) removeRoom(room *Room) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rooms[room.auctionID] == room {
+		delete(m.rooms, room.auctionID)
+	}
 }
 
 // ---- WebSocket Upgrade ----
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	// In production, restrict origins properly!
-	CheckOrigin: func(r *http.Request) bool { return true },
+// Session is who authenticated the handshake. A zero UserID is an anonymous
+// spectator.
+type Session struct {
+	UserID int64
+	// ExpiresAt is when the access token expires; the connection is closed
+	// then. Zero for anonymous sessions.
+	ExpiresAt time.Time
+	// Subprotocol is echoed back when the token was passed as a
+	// subprotocol.
+	Subprotocol string
+	// Permissions granted by the token's roles.
+	Permissions []string
+	// LastSeq and Epoch identify the last event a reconnecting client
+	// saw.
+	LastSeq *uint64
+	Epoch   string
 }
 
-// ServeAuctionWS upgrades to websocket and joins auction room
-func (m *Manager) ServeAuctionWS(c *gin.Context, auctionID int64) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		c.Status(http.StatusBadRequest)
-		return
+// HasPermission reports whether the session's roles grant perm.
+func (s Session) HasPermission(perm string) bool {
+	for _, p := range s.Permissions {
+		if p == perm {
+			return true
+		}
 	}
+	return false
+}
 
-	// If auth middleware exists, it will set user_id.
-	var userID int64 = 0
-	if v, ok := c.Get("user_id"); ok {
-		if id, ok2 := v.(int64); ok2 {
-			userID = id
-		}
+// ServeAuctionWS upgrades to websocket and joins auction room. backend runs
+// what the client sends and loads snapshots; with nil only PING is
+// answered and no snapshot is sent.
+func (m *Manager) ServeAuctionWS(c *gin.Context, auctionID int64, session Session, backend Backend) {
+	var header http.Header
+	if session.Subprotocol != "" {
+		header = http.Header{"Sec-WebSocket-Protocol": {session.Subprotocol}}
 	}
 
-	room := m.getOrCreateRoom(auctionID)
+	// a failed upgrade has already answered the request
+	conn, err := m.upgrader.Upgrade(c.Writer, c.Request, header)
+	if err != nil {
+		return
+	}
+
+	room := m.acquireRoom(auctionID)
 
 	client := &Client{
 		room:      room,
 		conn:      conn,
 		send:      make(chan []byte, 256),
-		userID:    userID,
+		session:   session,
 		auctionID: auctionID,
+		commands:  backend,
+		snapshots: backend,
 	}
 
-	room.register <- client
+	// A reconnecting client that can be caught up from the replay buffer
+	// only gets the events it missed. Anyone else gets a snapshot current
+	// as of from, and the room replays whatever happened since. Seqs from
+	// another epoch belong to a different numbering and never resume.
+	epoch, from := room.replay.head()
+	resumed := false
+	if session.LastSeq != nil {
+		if _, ok := room.replay.since(session.Epoch, *session.LastSeq); ok {
+			from, resumed = *session.LastSeq, true
+		} else {
+			client.send <- room.directEvent(EventResyncRequired, epoch, from, nil)
+		}
+	}
+	if !resumed {
+		client.send <- room.directEvent(EventConnected, epoch, from, gin.H{"user_id": session.UserID})
+		if backend != nil {
+			if snap, err := backend.AuctionSnapshot(auctionID); err == nil {
+				client.send <- room.directEvent(EventSnapshot, epoch, from, snap)
+			} else {
+				log.Println("WS snapshot error:", err)
+			}
+		}
+	}
+	client.resumeEpoch, client.resumeFrom = epoch, &from
 
-	// send a welcome event
-	_ = m.sendSystemEvent(auctionID, "WS_CONNECTED", gin.H{
-		"user_id": userID,
-	})
+	if !room.join(client) {
+		// the auction ended while the snapshot was loading
+		m.releaseRoom(room)
+		_ = conn.Close()
+		return
+	}
 
 	go client.writePump()
 	go client.readPump()
 }
 
-// BroadcastToAuction is used by your services to push events to frontend
+// BroadcastToAuction is used by your services to push events to frontend.
+// It never blocks: auctions nobody is watching here get no room, and an
+// event a backed-up room cannot take is dropped. Other instances receive it
+// through the pubsub either way.
 func (m *Manager) BroadcastToAuction(auctionID int64, eventType string, payload any) {
 	ev := Event{
 		Type:      eventType,
@@ -144,24 +613,46 @@ func (m *Manager) BroadcastToAuction(auctionID int64, eventType string, payload
 		Payload:   payload,
 		Timestamp: time.Now(),
 	}
-	b, _ := json.Marshal(ev)
-	room := m.getOrCreateRoom(auctionID)
-	room.broadcast <- b
+	if room := m.room(auctionID); room != nil {
+		room.publish(ev)
+	}
+
+	if m.pubsub != nil {
+		if err := m.pubsub.Publish(context.Background(), ev); err != nil {
+			log.Println("WS pubsub publish error:", err)
+		}
+	}
 }
 
-// helper: system events
-func (m *Manager) sendSystemEvent(auctionID int64, eventType string, payload any) error {
-	ev := Event{
-		Type:      eventType,
-		AuctionID: auctionID,
-		Payload:   payload,
-		Timestamp: time.Now(),
+// DisconnectUser closes every connection of the user in every room, e.g.
+// after the account has been disabled. With a pubsub the other instances
+// drop the user's connections too.
+func (m *Manager) DisconnectUser(userID int64) {
+	if userID <= 0 {
+		return
 	}
-	b, err := json.Marshal(ev)
-	if err != nil {
-		return err
+	m.disconnectLocal(userID)
+
+	if m.pubsub != nil {
+		ev := Event{Type: ctrlDisconnectUser, Payload: userID, Timestamp: time.Now()}
+		if err := m.pubsub.Publish(context.Background(), ev); err != nil {
+			log.Println("WS pubsub publish error:", err)
+		}
+	}
+}
+
+func (m *Manager) disconnectLocal(userID int64) {
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	for _, room := range rooms {
+		select {
+		case room.kick <- userID:
+		case <-room.done:
+		}
 	}
-	room := m.getOrCreateRoom(auctionID)
-	room.broadcast <- b
-	return nil
 }
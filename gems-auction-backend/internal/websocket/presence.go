@@ -0,0 +1,251 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+const (
+	// presenceInterval is the least time between two PRESENCE events of a
+	// room.
+	presenceInterval = 2 * time.Second
+	// activeBidderWindow is how long after a bid a viewer counts as an
+	// active bidder.
+	activeBidderWindow = 5 * time.Minute
+	// presenceHeartbeat is how often a room repeats its counts to the
+	// other instances when they have not changed. Reports older than
+	// presenceTTL are dropped, e.g. when an instance went away.
+	presenceHeartbeat = 30 * time.Second
+	presenceTTL       = 3 * presenceHeartbeat
+)
+
+// presence tracks who is connected to a room. Only the room's goroutine
+// changes it; current can be read from anywhere.
+type presence struct {
+	users     map[int64]int // open connections per signed-in user
+	anonymous int
+	bidders   map[int64]time.Time // last bid per user
+	changed   bool
+
+	sent     domain.AuctionPresence // last PRESENCE broadcast, all instances
+	shared   presenceReport         // last audience shared with other instances
+	sharedAt time.Time
+	current  atomic.Pointer[presenceReport]
+}
+
+func newPresence() *presence {
+	return &presence{
+		users:   make(map[int64]int),
+		bidders: make(map[int64]time.Time),
+	}
+}
+
+func (p *presence) join(s Session) {
+	if s.UserID == 0 {
+		p.anonymous++
+	} else {
+		p.users[s.UserID]++
+	}
+	p.changed = true
+}
+
+func (p *presence) leave(s Session) {
+	p.changed = true
+	if s.UserID == 0 {
+		p.anonymous--
+		return
+	}
+	p.users[s.UserID]--
+	if p.users[s.UserID] <= 0 {
+		delete(p.users, s.UserID)
+	}
+}
+
+// bid records a BID_PLACED event. Its payload is read back from the
+// encoded event, so events from other instances count too.
+func (p *presence) bid(msg []byte, at time.Time) {
+	var ev struct {
+		Payload struct {
+			UserID int64 `json:"user_id"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(msg, &ev) != nil || ev.Payload.UserID == 0 {
+		return
+	}
+	p.bidders[ev.Payload.UserID] = at
+	p.changed = true
+}
+
+// refresh lists the room's audience, forgetting bids older than
+// activeBidderWindow.
+func (p *presence) refresh(now time.Time) presenceReport {
+	local := presenceReport{Anonymous: p.anonymous}
+	for userID := range p.users {
+		local.Users = append(local.Users, userID)
+	}
+	for userID, at := range p.bidders {
+		if now.Sub(at) > activeBidderWindow {
+			delete(p.bidders, userID)
+		} else if p.users[userID] > 0 {
+			local.Bidders = append(local.Bidders, userID)
+		}
+	}
+	slices.Sort(local.Users)
+	slices.Sort(local.Bidders)
+
+	p.current.Store(&local)
+	p.changed = false
+	return local
+}
+
+// local returns the room's audience as of the last refresh.
+func (p *presence) local() presenceReport {
+	if cur := p.current.Load(); cur != nil {
+		return *cur
+	}
+	return presenceReport{}
+}
+
+// Presence reports who is watching the auction across all instances.
+func (m *Manager) Presence(auctionID int64) domain.AuctionPresence {
+	var local presenceReport
+	if room := m.room(auctionID); room != nil {
+		local = room.presence.local()
+	}
+	return m.remote.total(auctionID, local)
+}
+
+// maxSharedUsers is the most viewers an instance lists by id when it
+// shares its audience, keeping the report within the 8000 byte NOTIFY
+// payload of PostgresPubSub.
+const maxSharedUsers = 400
+
+// presenceReport is one instance's audience of an auction. Users and
+// Bidders list its signed-in viewers and active bidders so a user
+// connected to several instances counts once. Above maxSharedUsers only
+// their numbers are shared, in Viewers and ActiveBidders, and those are
+// added up instead.
+type presenceReport struct {
+	Instance      string  `json:"instance"`
+	Users         []int64 `json:"users,omitempty"`
+	Bidders       []int64 `json:"bidders,omitempty"`
+	Viewers       int     `json:"viewers,omitempty"`
+	ActiveBidders int     `json:"active_bidders,omitempty"`
+	Anonymous     int     `json:"anonymous,omitempty"`
+
+	received time.Time
+}
+
+func (rep presenceReport) empty() bool {
+	return len(rep.Users) == 0 && rep.Viewers == 0 && rep.Anonymous == 0
+}
+
+// sameAudience reports whether two reports list the same audience.
+func sameAudience(a, b presenceReport) bool {
+	return slices.Equal(a.Users, b.Users) && slices.Equal(a.Bidders, b.Bidders) &&
+		a.Viewers == b.Viewers && a.ActiveBidders == b.ActiveBidders && a.Anonymous == b.Anonymous
+}
+
+// remotePresence holds the latest audiences the other instances reported.
+type remotePresence struct {
+	mu        sync.Mutex
+	byAuction map[int64]map[string]presenceReport
+}
+
+func (r *remotePresence) set(auctionID int64, rep presenceReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reports := r.byAuction[auctionID]
+	if rep.empty() {
+		delete(reports, rep.Instance)
+		if len(reports) == 0 {
+			delete(r.byAuction, auctionID)
+		}
+		return
+	}
+	if reports == nil {
+		reports = make(map[string]presenceReport)
+		r.byAuction[auctionID] = reports
+	}
+	rep.received = time.Now()
+	reports[rep.Instance] = rep
+}
+
+// total counts the auction's audience across this instance's local
+// audience and the other instances' current reports.
+func (r *remotePresence) total(auctionID int64, local presenceReport) domain.AuctionPresence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sum domain.AuctionPresence
+	users := make(map[int64]bool)
+	bidders := make(map[int64]bool)
+	add := func(rep presenceReport) {
+		for _, userID := range rep.Users {
+			users[userID] = true
+		}
+		for _, userID := range rep.Bidders {
+			bidders[userID] = true
+		}
+		sum.Viewers += rep.Viewers
+		sum.ActiveBidders += rep.ActiveBidders
+		sum.Anonymous += rep.Anonymous
+	}
+
+	add(local)
+	reports := r.byAuction[auctionID]
+	for instance, rep := range reports {
+		if time.Since(rep.received) > presenceTTL {
+			delete(reports, instance)
+			continue
+		}
+		add(rep)
+	}
+	if reports != nil && len(reports) == 0 {
+		delete(r.byAuction, auctionID)
+	}
+
+	sum.Viewers += len(users)
+	sum.ActiveBidders += len(bidders)
+	return sum
+}
+
+// sharePresence queues this instance's audience for the other instances
+// without blocking the room; publishPresence sends them in order.
+func (m *Manager) sharePresence(auctionID int64, local presenceReport) {
+	if m.pubsub == nil {
+		return
+	}
+	local.Instance = m.instance
+	if len(local.Users) > maxSharedUsers {
+		local.Viewers, local.ActiveBidders = len(local.Users), len(local.Bidders)
+		local.Users, local.Bidders = nil, nil
+	}
+	ev := Event{
+		Type:      ctrlPresence,
+		AuctionID: auctionID,
+		Payload:   local,
+		Timestamp: time.Now(),
+	}
+	select {
+	case m.presenceShare <- ev:
+	default:
+		m.stats.droppedEvents.Add(1)
+	}
+}
+
+func (m *Manager) publishPresence() {
+	for ev := range m.presenceShare {
+		if err := m.pubsub.Publish(context.Background(), ev); err != nil {
+			log.Println("WS pubsub presence error:", err)
+		}
+	}
+}
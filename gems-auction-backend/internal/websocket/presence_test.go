@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boswin/gems-auction-backend/internal/domain"
+)
+
+func TestRemotePresenceTotalDedupesUsers(t *testing.T) {
+	r := remotePresence{byAuction: make(map[int64]map[string]presenceReport)}
+	r.set(1, presenceReport{Instance: "b", Users: []int64{2, 3}, Bidders: []int64{3}, Anonymous: 1})
+	r.set(1, presenceReport{Instance: "c", Viewers: 500, ActiveBidders: 4})
+
+	local := presenceReport{Users: []int64{1, 2, 3}, Bidders: []int64{2, 3}, Anonymous: 2}
+	got := r.total(1, local)
+	want := domain.AuctionPresence{Viewers: 503, Anonymous: 3, ActiveBidders: 6}
+	if got != want {
+		t.Fatalf("total = %+v, want %+v", got, want)
+	}
+
+	r.set(1, presenceReport{Instance: "c"})
+	if got := r.total(1, presenceReport{}); got != (domain.AuctionPresence{Viewers: 2, Anonymous: 1, ActiveBidders: 1}) {
+		t.Fatalf("total after c left = %+v", got)
+	}
+}
+
+func TestSharePresenceFallsBackToCounts(t *testing.T) {
+	m := NewManager(nil)
+	m.pubsub = nopPubSub{}
+
+	local := presenceReport{Bidders: []int64{1}}
+	for userID := int64(1); userID <= maxSharedUsers+1; userID++ {
+		local.Users = append(local.Users, userID)
+	}
+	m.sharePresence(1, local)
+
+	rep := (<-m.presenceShare).Payload.(presenceReport)
+	if rep.Users != nil || rep.Bidders != nil || rep.Viewers != maxSharedUsers+1 || rep.ActiveBidders != 1 {
+		t.Fatalf("shared report = %+v", rep)
+	}
+	if rep.Instance != m.instance {
+		t.Fatalf("instance = %q, want %q", rep.Instance, m.instance)
+	}
+}
+
+// nopPubSub stands in for the pubsub so the Manager shares presence.
+type nopPubSub struct{}
+
+func (nopPubSub) Publish(context.Context, Event) error { return nil }
+
+func (nopPubSub) Listen(ctx context.Context, _ func(), _ func(Event)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
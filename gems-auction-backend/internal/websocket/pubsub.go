@@ -0,0 +1,33 @@
+package websocket
+
+import (
+	"context"
+	"time"
+)
+
+// pubsubRetryDelay is how long the Manager waits before listening again
+// after the subscription failed.
+const pubsubRetryDelay = 2 * time.Second
+
+// ctrlDisconnectUser asks the other instances to drop a user's
+// connections. It travels like an event but is never sent to clients; its
+// payload is the user id.
+const ctrlDisconnectUser = "_DISCONNECT_USER"
+
+// ctrlPresence reports an instance's presence counts for an auction; its
+// payload is a presenceReport.
+const ctrlPresence = "_PRESENCE"
+
+// PubSub carries auction events, and control messages such as
+// ctrlDisconnectUser, between server instances so viewers connected to any
+// replica see every event. Implementations never hand an
+// instance back its own events: the Manager has already delivered those
+// to its local rooms.
+type PubSub interface {
+	// Publish sends ev to the other instances.
+	Publish(ctx context.Context, ev Event) error
+	// Listen calls subscribed once it is subscribed, then deliver for each
+	// event published by another instance until ctx is done or the
+	// subscription fails.
+	Listen(ctx context.Context, subscribed func(), deliver func(Event)) error
+}
@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// pgEventChannel is the LISTEN/NOTIFY channel shared by all instances.
+	pgEventChannel = "auction_events"
+	// pgMaxPayload is the largest NOTIFY payload Postgres accepts by default.
+	pgMaxPayload = 8000
+)
+
+var ErrEventTooLarge = errors.New("event too large to publish")
+
+// PostgresPubSub fans events out through Postgres LISTEN/NOTIFY. Every
+// notification is tagged with the publishing instance, so an instance
+// skips its own.
+type PostgresPubSub struct {
+	pool     *pgxpool.Pool
+	instance string
+}
+
+// pgEnvelope is the NOTIFY payload. Payload stays raw so it is forwarded to
+// clients exactly as published.
+type pgEnvelope struct {
+	Instance string `json:"instance"`
+	Event    struct {
+		Type      string          `json:"type"`
+		AuctionID int64           `json:"auction_id"`
+		Payload   json.RawMessage `json:"payload"`
+		Timestamp time.Time       `json:"timestamp"`
+	} `json:"event"`
+}
+
+func NewPostgresPubSub(pool *pgxpool.Pool) *PostgresPubSub {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return &PostgresPubSub{pool: pool, instance: hex.EncodeToString(b)}
+}
+
+// Publish notifies the other instances. Events are not numbered: each
+// instance numbers them in its own rooms.
+func (p *PostgresPubSub) Publish(ctx context.Context, ev Event) error {
+	ev.Seq, ev.Epoch = 0, ""
+	payload, err := json.Marshal(struct {
+		Instance string `json:"instance"`
+		Event    Event  `json:"event"`
+	}{p.instance, ev})
+	if err != nil {
+		return err
+	}
+	if len(payload) > pgMaxPayload {
+		return ErrEventTooLarge
+	}
+
+	_, err = p.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, pgEventChannel, string(payload))
+	return err
+}
+
+// Listen holds a connection of its own, outside the pool, for as long as
+// it listens.
+func (p *PostgresPubSub) Listen(ctx context.Context, subscribed func(), deliver func(Event)) error {
+	conn, err := pgx.ConnectConfig(ctx, p.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgEventChannel); err != nil {
+		return err
+	}
+	subscribed()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var env pgEnvelope
+		if err := json.Unmarshal([]byte(n.Payload), &env); err != nil || env.Instance == p.instance {
+			continue
+		}
+
+		deliver(Event{
+			Type:      env.Event.Type,
+			AuctionID: env.Event.AuctionID,
+			Payload:   env.Event.Payload,
+			Timestamp: env.Event.Timestamp,
+		})
+	}
+}
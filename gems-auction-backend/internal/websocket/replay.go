@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// replayBufferSize is how many recent events a room keeps for clients that
+// reconnect with last_seq.
+const replayBufferSize = 256
+
+// replayBuffer numbers a room's events and keeps the most recent ones. Seq
+// starts at 1 for the first event; it restarts under a new epoch when the
+// room is recreated, e.g. after it shut down idle or a server restart, and
+// when the room may have missed events from the other instances.
+type replayBuffer struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	events [replayBufferSize][]byte
+	size   int
+}
+
+// add assigns ev the next sequence number, stores it and returns it encoded.
+func (b *replayBuffer) add(ev *Event) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ev.Seq = b.seq + 1
+	ev.Epoch = b.epoch
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	b.seq++
+	b.events[b.seq%replayBufferSize] = msg
+	if b.size < replayBufferSize {
+		b.size++
+	}
+	return msg, nil
+}
+
+// randomID returns a random hex identifier, such as a room's epoch.
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// restart drops the buffered events and numbers from scratch under a new
+// epoch.
+func (b *replayBuffer) restart() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.epoch = randomID()
+	b.seq, b.size = 0, 0
+	b.events = [replayBufferSize][]byte{}
+}
+
+// last returns the sequence number of the latest event.
+func (b *replayBuffer) last() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+// head returns the current epoch and the sequence number of its latest
+// event.
+func (b *replayBuffer) head() (string, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.epoch, b.seq
+}
+
+// since returns the events after seq of epoch. It returns false when some
+// of them are no longer buffered, or when seq was never issued under the
+// current epoch.
+func (b *replayBuffer) since(epoch string, seq uint64) ([][]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if epoch != b.epoch || seq > b.seq {
+		return nil, false
+	}
+	oldest := b.seq - uint64(b.size) + 1
+	if seq+1 < oldest {
+		return nil, false
+	}
+
+	out := make([][]byte, 0, b.seq-seq)
+	for s := seq + 1; s <= b.seq; s++ {
+		out = append(out, b.events[s%replayBufferSize])
+	}
+	return out, true
+}
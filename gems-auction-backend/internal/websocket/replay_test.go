@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// fill adds n events to b.
+func fill(t *testing.T, b *replayBuffer, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := b.add(&Event{Type: "TEST"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// seqs decodes the seq of each message.
+func seqs(t *testing.T, msgs [][]byte) []uint64 {
+	t.Helper()
+	out := make([]uint64, len(msgs))
+	for i, msg := range msgs {
+		var ev Event
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			t.Fatal(err)
+		}
+		out[i] = ev.Seq
+	}
+	return out
+}
+
+func TestReplayBufferSince(t *testing.T) {
+	b := replayBuffer{epoch: "e1"}
+
+	if msgs, ok := b.since("e1", 0); !ok || len(msgs) != 0 {
+		t.Fatalf("empty buffer: since(0) = %d events, %v", len(msgs), ok)
+	}
+	if _, ok := b.since("e1", 1); ok {
+		t.Fatal("empty buffer: since(1) should fail, seq 1 was never issued")
+	}
+
+	fill(t, &b, 3)
+	got, ok := b.since("e1", 1)
+	if !ok || len(got) != 2 || seqs(t, got)[0] != 2 || seqs(t, got)[1] != 3 {
+		t.Fatalf("since(1) = %v, %v; want [2 3]", seqs(t, got), ok)
+	}
+	if got, ok := b.since("e1", 3); !ok || len(got) != 0 {
+		t.Fatalf("since(last) = %d events, %v; want none", len(got), ok)
+	}
+	if _, ok := b.since("e1", 4); ok {
+		t.Fatal("since(4) should fail, seq 4 was never issued")
+	}
+	if _, ok := b.since("e0", 1); ok {
+		t.Fatal("since should fail for another epoch")
+	}
+}
+
+func TestReplayBufferWraparound(t *testing.T) {
+	b := replayBuffer{epoch: "e1"}
+	fill(t, &b, replayBufferSize+44)
+	last := uint64(replayBufferSize + 44)
+	oldest := last - replayBufferSize + 1 // 45
+
+	// the client saw oldest-1, so it needs oldest onwards: all buffered
+	got, ok := b.since("e1", oldest-1)
+	if !ok || len(got) != replayBufferSize {
+		t.Fatalf("since(oldest-1) = %d events, %v; want %d", len(got), ok, replayBufferSize)
+	}
+	s := seqs(t, got)
+	for i, seq := range s {
+		if seq != oldest+uint64(i) {
+			t.Fatalf("event %d has seq %d, want %d", i, seq, oldest+uint64(i))
+		}
+	}
+
+	// oldest-1 itself has been overwritten
+	if _, ok := b.since("e1", oldest-2); ok {
+		t.Fatal("since(oldest-2) should fail, seq oldest-1 is no longer buffered")
+	}
+
+	got, ok = b.since("e1", last-2)
+	if s := seqs(t, got); !ok || len(s) != 2 || s[0] != last-1 || s[1] != last {
+		t.Fatalf("since(last-2) = %v, %v; want [%d %d]", s, ok, last-1, last)
+	}
+	if _, ok := b.since("e1", last+1); ok {
+		t.Fatal("since(last+1) should fail, it was never issued")
+	}
+}
+
+func TestReplayBufferRestart(t *testing.T) {
+	b := replayBuffer{epoch: "e1"}
+	fill(t, &b, 5)
+	b.restart()
+
+	epoch, seq := b.head()
+	if epoch == "e1" || seq != 0 {
+		t.Fatalf("head after restart = %q, %d", epoch, seq)
+	}
+	if _, ok := b.since("e1", 5); ok {
+		t.Fatal("seqs of the old epoch should not resume")
+	}
+	if _, ok := b.since(epoch, 1); ok {
+		t.Fatal("since(1) should fail before anything is added")
+	}
+
+	msg, err := b.add(&Event{Type: "TEST"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev Event
+	if err := json.Unmarshal(msg, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Seq != 1 || ev.Epoch != epoch {
+		t.Fatalf("first event after restart = seq %d epoch %q; want 1 %q", ev.Seq, ev.Epoch, epoch)
+	}
+}
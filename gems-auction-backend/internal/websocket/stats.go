@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// statsInterval is the window messages per second is measured over.
+const statsInterval = 10 * time.Second
+
+// hubCounters are updated by the rooms as they deliver.
+type hubCounters struct {
+	messagesSent   atomic.Uint64
+	droppedClients atomic.Uint64
+	droppedEvents  atomic.Uint64
+
+	// rate holds the float64 bits of the last measured messages per
+	// second.
+	rate atomic.Uint64
+}
+
+// sample measures the send rate every statsInterval for as long as the
+// process runs.
+func (s *hubCounters) sample() {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	prev := s.messagesSent.Load()
+	for range ticker.C {
+		sent := s.messagesSent.Load()
+		s.rate.Store(math.Float64bits(float64(sent-prev) / statsInterval.Seconds()))
+		prev = sent
+	}
+}
+
+// HubStats describes this instance's rooms. Counters are totals since the
+// process started.
+type HubStats struct {
+	Rooms   int `json:"rooms"`
+	Clients int `json:"clients"`
+	// DroppedSlowClients counts connections dropped for falling behind.
+	DroppedSlowClients uint64 `json:"dropped_slow_clients"`
+	// DroppedEvents counts broadcasts a backed-up room could not take.
+	DroppedEvents uint64 `json:"dropped_events"`
+	// MessagesPerSecond is measured over the last statsInterval.
+	MessagesPerSecond float64     `json:"messages_per_second"`
+	PerRoom           []RoomStats `json:"per_room"`
+}
+
+type RoomStats struct {
+	AuctionID int64  `json:"auction_id"`
+	Clients   int    `json:"clients"`
+	LastSeq   uint64 `json:"last_seq"`
+}
+
+// Stats reports the rooms running on this instance, busiest first.
+func (m *Manager) Stats() HubStats {
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	st := HubStats{
+		Rooms:              len(rooms),
+		DroppedSlowClients: m.stats.droppedClients.Load(),
+		DroppedEvents:      m.stats.droppedEvents.Load(),
+		MessagesPerSecond:  math.Float64frombits(m.stats.rate.Load()),
+		PerRoom:            make([]RoomStats, 0, len(rooms)),
+	}
+	for _, room := range rooms {
+		rs := RoomStats{
+			AuctionID: room.auctionID,
+			Clients:   int(room.clientCount.Load()),
+			LastSeq:   room.replay.last(),
+		}
+		st.Clients += rs.Clients
+		st.PerRoom = append(st.PerRoom, rs)
+	}
+	sort.Slice(st.PerRoom, func(i, j int) bool {
+		if st.PerRoom[i].Clients != st.PerRoom[j].Clients {
+			return st.PerRoom[i].Clients > st.PerRoom[j].Clients
+		}
+		return st.PerRoom[i].AuctionID < st.PerRoom[j].AuctionID
+	})
+	return st
+}
@@ -0,0 +1,14 @@
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS reserve_price NUMERIC(15,2);
+
+CREATE TABLE IF NOT EXISTS auction_changes (
+    id BIGSERIAL PRIMARY KEY,
+    auction_id BIGINT NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+    changed_by BIGINT NOT NULL REFERENCES users(id),
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    auction_status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auction_changes_auction_id ON auction_changes(auction_id);
@@ -0,0 +1,10 @@
+CREATE INDEX IF NOT EXISTS idx_auctions_gem_id ON auctions(gem_id);
+CREATE INDEX IF NOT EXISTS idx_auctions_status_end_time ON auctions(status, end_time, id);
+CREATE INDEX IF NOT EXISTS idx_auctions_current_price ON auctions(current_price, id);
+CREATE INDEX IF NOT EXISTS idx_auctions_created_at ON auctions(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_auctions_end_time ON auctions(end_time, id);
+
+CREATE INDEX IF NOT EXISTS idx_gems_carat ON gems(carat);
+CREATE INDEX IF NOT EXISTS idx_gems_color_lower ON gems(LOWER(color));
+CREATE INDEX IF NOT EXISTS idx_gems_clarity_lower ON gems(LOWER(clarity));
+CREATE INDEX IF NOT EXISTS idx_gems_origin_lower ON gems(LOWER(origin));
@@ -0,0 +1,10 @@
+ALTER TABLE gems ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(color, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(origin, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(clarity, '')), 'C') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_gems_search_vector ON gems USING GIN (search_vector);
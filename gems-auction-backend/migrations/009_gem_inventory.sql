@@ -0,0 +1,17 @@
+ALTER TABLE gems DROP CONSTRAINT IF EXISTS gems_status_check;
+ALTER TABLE gems ADD CONSTRAINT gems_status_check
+    CHECK (status IN ('AVAILABLE','AUCTION','SOLD','WITHDRAWN'));
+
+CREATE INDEX IF NOT EXISTS idx_gems_seller_status ON gems(seller_id, status);
+
+CREATE TABLE IF NOT EXISTS gem_edits (
+    id BIGSERIAL PRIMARY KEY,
+    gem_id BIGINT NOT NULL REFERENCES gems(id) ON DELETE CASCADE,
+    edited_by BIGINT NOT NULL REFERENCES users(id),
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_gem_edits_gem_id ON gem_edits(gem_id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS gem_images (
+    id BIGSERIAL PRIMARY KEY,
+    gem_id BIGINT NOT NULL REFERENCES gems(id) ON DELETE CASCADE,
+    original_key TEXT NOT NULL,
+    web_key TEXT NOT NULL,
+    thumb_key TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_gem_images_gem_id ON gem_images(gem_id, position);
+CREATE UNIQUE INDEX uq_gem_images_primary ON gem_images(gem_id) WHERE is_primary;
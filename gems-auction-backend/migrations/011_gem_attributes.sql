@@ -0,0 +1,203 @@
+-- Controlled vocabularies for structured gem attributes
+CREATE TABLE IF NOT EXISTS gem_vocabularies (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN (
+        'SPECIES','VARIETY','TREATMENT','CUT','SHAPE','COLOR_HUE','COLOR_TONE','COLOR_SATURATION'
+    )),
+    code VARCHAR(50) NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    parent_code VARCHAR(50),
+    sort_order INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, code)
+);
+
+INSERT INTO gem_vocabularies (kind, code, label, parent_code, sort_order) VALUES
+    ('SPECIES', 'CORUNDUM', 'Corundum', NULL, 1),
+    ('SPECIES', 'BERYL', 'Beryl', NULL, 2),
+    ('SPECIES', 'CHRYSOBERYL', 'Chrysoberyl', NULL, 3),
+    ('SPECIES', 'SPINEL', 'Spinel', NULL, 4),
+    ('SPECIES', 'GARNET', 'Garnet', NULL, 5),
+    ('SPECIES', 'TOURMALINE', 'Tourmaline', NULL, 6),
+    ('SPECIES', 'TOPAZ', 'Topaz', NULL, 7),
+    ('SPECIES', 'QUARTZ', 'Quartz', NULL, 8),
+    ('SPECIES', 'ZIRCON', 'Zircon', NULL, 9),
+    ('SPECIES', 'DIAMOND', 'Diamond', NULL, 10),
+
+    ('VARIETY', 'RUBY', 'Ruby', 'CORUNDUM', 1),
+    ('VARIETY', 'SAPPHIRE', 'Sapphire', 'CORUNDUM', 2),
+    ('VARIETY', 'PADPARADSCHA', 'Padparadscha', 'CORUNDUM', 3),
+    ('VARIETY', 'EMERALD', 'Emerald', 'BERYL', 4),
+    ('VARIETY', 'AQUAMARINE', 'Aquamarine', 'BERYL', 5),
+    ('VARIETY', 'MORGANITE', 'Morganite', 'BERYL', 6),
+    ('VARIETY', 'ALEXANDRITE', 'Alexandrite', 'CHRYSOBERYL', 7),
+    ('VARIETY', 'CHRYSOBERYL_CATS_EYE', 'Cat''s Eye', 'CHRYSOBERYL', 8),
+    ('VARIETY', 'SPINEL', 'Spinel', 'SPINEL', 9),
+    ('VARIETY', 'TSAVORITE', 'Tsavorite', 'GARNET', 10),
+    ('VARIETY', 'RHODOLITE', 'Rhodolite', 'GARNET', 11),
+    ('VARIETY', 'PARAIBA', 'Paraiba', 'TOURMALINE', 12),
+    ('VARIETY', 'RUBELLITE', 'Rubellite', 'TOURMALINE', 13),
+    ('VARIETY', 'IMPERIAL_TOPAZ', 'Imperial Topaz', 'TOPAZ', 14),
+    ('VARIETY', 'AMETHYST', 'Amethyst', 'QUARTZ', 15),
+    ('VARIETY', 'CITRINE', 'Citrine', 'QUARTZ', 16),
+    ('VARIETY', 'ZIRCON', 'Zircon', 'ZIRCON', 17),
+    ('VARIETY', 'DIAMOND', 'Diamond', 'DIAMOND', 18),
+
+    ('TREATMENT', 'UNHEATED', 'Unheated', NULL, 1),
+    ('TREATMENT', 'HEATED', 'Heated', NULL, 2),
+    ('TREATMENT', 'HEATED_RESIDUE', 'Heated with residue', NULL, 3),
+    ('TREATMENT', 'OILED', 'Oiled', NULL, 4),
+    ('TREATMENT', 'RESIN_FILLED', 'Resin filled', NULL, 5),
+    ('TREATMENT', 'GLASS_FILLED', 'Glass filled', NULL, 6),
+    ('TREATMENT', 'BERYLLIUM_DIFFUSED', 'Beryllium diffused', NULL, 7),
+    ('TREATMENT', 'IRRADIATED', 'Irradiated', NULL, 8),
+    ('TREATMENT', 'DYED', 'Dyed', NULL, 9),
+
+    ('CUT', 'BRILLIANT', 'Brilliant', NULL, 1),
+    ('CUT', 'STEP', 'Step', NULL, 2),
+    ('CUT', 'MIXED', 'Mixed', NULL, 3),
+    ('CUT', 'CABOCHON', 'Cabochon', NULL, 4),
+    ('CUT', 'ROSE', 'Rose', NULL, 5),
+    ('CUT', 'CARVED', 'Carved', NULL, 6),
+
+    ('SHAPE', 'ROUND', 'Round', NULL, 1),
+    ('SHAPE', 'OVAL', 'Oval', NULL, 2),
+    ('SHAPE', 'CUSHION', 'Cushion', NULL, 3),
+    ('SHAPE', 'OCTAGON', 'Octagon', NULL, 4),
+    ('SHAPE', 'PEAR', 'Pear', NULL, 5),
+    ('SHAPE', 'MARQUISE', 'Marquise', NULL, 6),
+    ('SHAPE', 'HEART', 'Heart', NULL, 7),
+    ('SHAPE', 'TRILLION', 'Trillion', NULL, 8),
+    ('SHAPE', 'PRINCESS', 'Princess', NULL, 9),
+    ('SHAPE', 'RADIANT', 'Radiant', NULL, 10),
+
+    ('COLOR_HUE', 'RED', 'Red', NULL, 1),
+    ('COLOR_HUE', 'ORANGE', 'Orange', NULL, 2),
+    ('COLOR_HUE', 'YELLOW', 'Yellow', NULL, 3),
+    ('COLOR_HUE', 'GREEN', 'Green', NULL, 4),
+    ('COLOR_HUE', 'BLUE', 'Blue', NULL, 5),
+    ('COLOR_HUE', 'VIOLET', 'Violet', NULL, 6),
+    ('COLOR_HUE', 'PURPLE', 'Purple', NULL, 7),
+    ('COLOR_HUE', 'PINK', 'Pink', NULL, 8),
+    ('COLOR_HUE', 'BROWN', 'Brown', NULL, 9),
+    ('COLOR_HUE', 'GRAY', 'Gray', NULL, 10),
+    ('COLOR_HUE', 'BLACK', 'Black', NULL, 11),
+    ('COLOR_HUE', 'WHITE', 'White', NULL, 12),
+    ('COLOR_HUE', 'COLORLESS', 'Colorless', NULL, 13),
+
+    ('COLOR_TONE', 'VERY_LIGHT', 'Very light', NULL, 1),
+    ('COLOR_TONE', 'LIGHT', 'Light', NULL, 2),
+    ('COLOR_TONE', 'MEDIUM_LIGHT', 'Medium light', NULL, 3),
+    ('COLOR_TONE', 'MEDIUM', 'Medium', NULL, 4),
+    ('COLOR_TONE', 'MEDIUM_DARK', 'Medium dark', NULL, 5),
+    ('COLOR_TONE', 'DARK', 'Dark', NULL, 6),
+    ('COLOR_TONE', 'VERY_DARK', 'Very dark', NULL, 7),
+
+    ('COLOR_SATURATION', 'GRAYISH', 'Grayish', NULL, 1),
+    ('COLOR_SATURATION', 'SLIGHTLY_GRAYISH', 'Slightly grayish', NULL, 2),
+    ('COLOR_SATURATION', 'MODERATE', 'Moderate', NULL, 3),
+    ('COLOR_SATURATION', 'STRONG', 'Strong', NULL, 4),
+    ('COLOR_SATURATION', 'VIVID', 'Vivid', NULL, 5)
+ON CONFLICT (kind, code) DO NOTHING;
+
+-- Structured attribute columns (codes from gem_vocabularies)
+ALTER TABLE gems
+    ADD COLUMN IF NOT EXISTS species VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS variety VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS treatment VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS cut VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS shape VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS length_mm NUMERIC(8,2),
+    ADD COLUMN IF NOT EXISTS width_mm NUMERIC(8,2),
+    ADD COLUMN IF NOT EXISTS depth_mm NUMERIC(8,2),
+    ADD COLUMN IF NOT EXISTS color_hue VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS color_tone VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS color_saturation VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_gems_species ON gems(species);
+CREATE INDEX IF NOT EXISTS idx_gems_variety ON gems(variety);
+CREATE INDEX IF NOT EXISTS idx_gems_treatment ON gems(treatment);
+CREATE INDEX IF NOT EXISTS idx_gems_color_hue ON gems(color_hue);
+
+-- Map existing free-text values onto the vocabularies.
+
+-- variety (and its species) from the gem name, longest label first
+UPDATE gems g SET variety = (
+    SELECT v.code FROM gem_vocabularies v
+    WHERE v.kind = 'VARIETY' AND g.name ILIKE '%' || v.label || '%'
+    ORDER BY LENGTH(v.label) DESC LIMIT 1
+)
+WHERE g.variety IS NULL;
+
+UPDATE gems g SET species = v.parent_code
+FROM gem_vocabularies v
+WHERE v.kind = 'VARIETY' AND v.code = g.variety AND g.species IS NULL;
+
+-- hue from the free-text color, longest label first
+UPDATE gems g SET color_hue = (
+    SELECT v.code FROM gem_vocabularies v
+    WHERE v.kind = 'COLOR_HUE' AND g.color ILIKE '%' || v.label || '%'
+    ORDER BY LENGTH(v.label) DESC LIMIT 1
+)
+WHERE g.color_hue IS NULL AND COALESCE(g.color, '') <> '';
+
+-- common trade color names carry tone and saturation too
+UPDATE gems g SET
+    color_hue = COALESCE(g.color_hue, t.hue),
+    color_tone = COALESCE(g.color_tone, t.tone),
+    color_saturation = COALESCE(g.color_saturation, t.saturation)
+FROM (VALUES
+    ('royal blue', 'BLUE', 'MEDIUM_DARK', 'VIVID'),
+    ('cornflower', 'BLUE', 'MEDIUM_LIGHT', 'STRONG'),
+    ('pigeon blood', 'RED', 'MEDIUM_DARK', 'VIVID'),
+    ('pigeon''s blood', 'RED', 'MEDIUM_DARK', 'VIVID'),
+    ('vivid', NULL, NULL, 'VIVID'),
+    ('pastel', NULL, 'LIGHT', 'MODERATE')
+) AS t(pattern, hue, tone, saturation)
+WHERE g.color ILIKE '%' || t.pattern || '%';
+
+-- treatment from the name or description
+UPDATE gems SET treatment = 'UNHEATED'
+WHERE treatment IS NULL
+  AND (name || ' ' || COALESCE(description, '')) ~* '(unheated|no heat|non-heated|not heated)';
+
+UPDATE gems SET treatment = 'HEATED'
+WHERE treatment IS NULL
+  AND (name || ' ' || COALESCE(description, '')) ~* '\mheated\M';
+
+UPDATE gems SET treatment = 'OILED'
+WHERE treatment IS NULL
+  AND (name || ' ' || COALESCE(description, '')) ~* '\moil(ed)?\M';
+
+-- cut and shape from the name or description
+UPDATE gems g SET shape = (
+    SELECT v.code FROM gem_vocabularies v
+    WHERE v.kind = 'SHAPE' AND (g.name || ' ' || COALESCE(g.description, '')) ~* ('\m' || v.label || '\M')
+    ORDER BY v.sort_order LIMIT 1
+)
+WHERE g.shape IS NULL;
+
+UPDATE gems g SET cut = (
+    SELECT v.code FROM gem_vocabularies v
+    WHERE v.kind = 'CUT' AND (g.name || ' ' || COALESCE(g.description, '')) ~* ('\m' || v.label || '\M')
+    ORDER BY v.sort_order LIMIT 1
+)
+WHERE g.cut IS NULL;
+
+-- Rebuild the search vector to cover the structured attributes.
+ALTER TABLE gems DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE gems ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(variety, '') || ' ' || COALESCE(species, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(color, '') || ' ' || COALESCE(color_hue, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(origin, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(treatment, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(clarity, '')), 'C') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_gems_search_vector ON gems USING GIN (search_vector);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS gem_certificates (
+    id BIGSERIAL PRIMARY KEY,
+    gem_id BIGINT NOT NULL REFERENCES gems(id) ON DELETE CASCADE,
+    lab VARCHAR(20) NOT NULL,
+    report_number VARCHAR(100) NOT NULL,
+    issue_date DATE,
+    pdf_key TEXT,
+    stated_attributes JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING','VERIFIED','REJECTED')),
+    submitted_by BIGINT NOT NULL REFERENCES users(id),
+    reviewed_by BIGINT REFERENCES users(id),
+    reviewed_at TIMESTAMP,
+    review_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- one lab report can only ever describe one stone
+    UNIQUE (lab, report_number)
+);
+
+CREATE INDEX idx_gem_certificates_gem_id ON gem_certificates(gem_id);
+CREATE INDEX idx_gem_certificates_status ON gem_certificates(status);
+
+-- carry over the legacy free-text certificate numbers for review
+INSERT INTO gem_certificates (gem_id, lab, report_number, submitted_by, created_at, updated_at)
+SELECT id, 'OTHER', UPPER(REGEXP_REPLACE(certificate, '\s', '', 'g')), seller_id, created_at, updated_at
+FROM gems
+WHERE COALESCE(certificate, '') <> ''
+ON CONFLICT (lab, report_number) DO NOTHING;
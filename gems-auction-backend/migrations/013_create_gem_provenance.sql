@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS gem_provenance (
+    id BIGSERIAL PRIMARY KEY,
+    gem_id BIGINT NOT NULL REFERENCES gems(id),
+    event_type VARCHAR(30) NOT NULL CHECK (event_type IN (
+        'ORIGIN_CLAIM','CUTTER','PREVIOUS_OWNER','PLATFORM_SALE','NOTE'
+    )),
+    party_name VARCHAR(255),
+    location VARCHAR(255),
+    description TEXT,
+    -- platform sales: seller -> winning buyer
+    auction_id BIGINT REFERENCES auctions(id),
+    from_user_id BIGINT REFERENCES users(id),
+    to_user_id BIGINT REFERENCES users(id),
+    amount NUMERIC(15,2),
+    occurred_at TIMESTAMP,
+    recorded_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_gem_provenance_gem_id ON gem_provenance(gem_id);
+
+-- The provenance log is append-only.
+CREATE OR REPLACE FUNCTION gem_provenance_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'gem_provenance is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_gem_provenance_append_only ON gem_provenance;
+CREATE TRIGGER trg_gem_provenance_append_only
+    BEFORE UPDATE OR DELETE ON gem_provenance
+    FOR EACH ROW EXECUTE FUNCTION gem_provenance_append_only();
+
+-- backfill sales of auctions that already ended with a winner
+INSERT INTO gem_provenance (gem_id, event_type, description, auction_id, from_user_id, to_user_id, amount, occurred_at, created_at)
+SELECT a.gem_id, 'PLATFORM_SALE', 'Sold at auction #' || a.id, a.id, g.seller_id, a.winner_id, a.current_price, a.updated_at, NOW()
+FROM auctions a
+JOIN gems g ON g.id = a.gem_id
+WHERE a.status = 'ENDED' AND a.winner_id IS NOT NULL;
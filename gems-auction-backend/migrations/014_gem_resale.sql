@@ -0,0 +1,27 @@
+-- Each auction remembers who listed it, so past sales keep their seller
+-- after the gem changes hands.
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS seller_id BIGINT REFERENCES users(id);
+
+UPDATE auctions a SET seller_id = g.seller_id
+FROM gems g
+WHERE g.id = a.gem_id AND a.seller_id IS NULL;
+
+ALTER TABLE auctions ALTER COLUMN seller_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_auctions_seller_id ON auctions(seller_id);
+
+-- Gems held by an open auction are marked AUCTION.
+UPDATE gems g SET status = 'AUCTION'
+WHERE g.status = 'AVAILABLE'
+  AND EXISTS (SELECT 1 FROM auctions a WHERE a.gem_id = g.id AND a.status IN ('SCHEDULED','LIVE'));
+
+-- Gems sold before ownership transfer existed pass to the winner of their
+-- latest sale and become available for relisting.
+UPDATE gems g SET seller_id = s.winner_id, status = 'AVAILABLE', updated_at = NOW()
+FROM (
+    SELECT DISTINCT ON (gem_id) gem_id, winner_id
+    FROM auctions
+    WHERE status = 'ENDED' AND winner_id IS NOT NULL
+    ORDER BY gem_id, updated_at DESC, id DESC
+) s
+WHERE g.id = s.gem_id AND g.status = 'SOLD';
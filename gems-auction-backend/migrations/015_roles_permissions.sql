@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(30) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(30) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(30) NOT NULL REFERENCES roles(name),
+    granted_by BIGINT REFERENCES users(id),
+    granted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(role);
+
+INSERT INTO roles (name, description) VALUES
+    ('ADMIN',  'Platform administrator'),
+    ('SELLER', 'Lists gems and runs auctions'),
+    ('BUYER',  'Bids on auctions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('gem:create',         'Create gems and manage own gem listings'),
+    ('gem:moderate',       'Edit or withdraw any gem'),
+    ('auction:create',     'Create and run auctions for owned gems'),
+    ('auction:moderate',   'Edit, start or end any auction'),
+    ('bid:place',          'Place bids'),
+    ('certificate:review', 'Verify or reject gem certificates'),
+    ('vocabulary:manage',  'Manage gem attribute vocabularies'),
+    ('payment:refund',     'Refund payments'),
+    ('user:manage',        'Manage users and their roles')
+ON CONFLICT (name) DO NOTHING;
+
+-- The BUYER auction:create grant below is withdrawn by migration 028:
+-- buyers relist gems they have won through auction:relist instead.
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'gem:create'),
+    ('ADMIN', 'gem:moderate'),
+    ('ADMIN', 'auction:create'),
+    ('ADMIN', 'auction:moderate'),
+    ('ADMIN', 'bid:place'),
+    ('ADMIN', 'certificate:review'),
+    ('ADMIN', 'vocabulary:manage'),
+    ('ADMIN', 'payment:refund'),
+    ('ADMIN', 'user:manage'),
+    ('SELLER', 'gem:create'),
+    ('SELLER', 'auction:create'),
+    ('BUYER', 'auction:create'),
+    ('BUYER', 'bid:place')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role, granted_at)
+SELECT id, role, created_at FROM users
+ON CONFLICT DO NOTHING;
+
+-- users.role is kept as the user's primary role for display; authorization
+-- uses user_roles.
+COMMENT ON COLUMN users.role IS 'Primary role for display; see user_roles';
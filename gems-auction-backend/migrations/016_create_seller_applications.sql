@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS seller_applications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    business_name VARCHAR(255) NOT NULL,
+    business_type VARCHAR(30) NOT NULL CHECK (business_type IN ('INDIVIDUAL','COMPANY','PARTNERSHIP')),
+    registration_number VARCHAR(100),
+    tax_id VARCHAR(100),
+    phone VARCHAR(50) NOT NULL,
+    address TEXT NOT NULL,
+    website VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING','APPROVED','REJECTED')),
+    reviewed_by BIGINT REFERENCES users(id),
+    reviewed_at TIMESTAMP,
+    review_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_seller_applications_user_id ON seller_applications(user_id);
+CREATE INDEX idx_seller_applications_status ON seller_applications(status);
+
+-- one open application per user
+CREATE UNIQUE INDEX idx_seller_applications_one_pending
+    ON seller_applications(user_id) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS seller_application_documents (
+    id BIGSERIAL PRIMARY KEY,
+    application_id BIGINT NOT NULL REFERENCES seller_applications(id) ON DELETE CASCADE,
+    doc_type VARCHAR(30) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    blob_key TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_seller_application_documents_application_id ON seller_application_documents(application_id);
@@ -0,0 +1,24 @@
+-- Consecutive failed logins per account. locked_until is set once the
+-- count passes the lockout threshold and grows with every further failure.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_count INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- Every login attempt, successful or not. user_id is NULL when the email
+-- did not match an account. Failures per IP are counted from here.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(64),
+    user_agent TEXT,
+    result VARCHAR(30) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_user_id ON login_attempts(user_id, created_at DESC);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip, created_at DESC);
+
+-- Locked accounts are mailed an unlock link.
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('EMAIL_VERIFY','PASSWORD_RESET','ACCOUNT_UNLOCK'));
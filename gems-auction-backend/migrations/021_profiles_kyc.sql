@@ -0,0 +1,72 @@
+-- Profile details and the KYC level reached through approved submissions:
+-- 0 = unverified, 1 = identity verified, 2 = business verified.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(50);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tax_id VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_level SMALLINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label VARCHAR(50),
+    recipient_name VARCHAR(255) NOT NULL,
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255),
+    city VARCHAR(100) NOT NULL,
+    region VARCHAR(100),
+    postal_code VARCHAR(20),
+    country CHAR(2) NOT NULL,
+    phone VARCHAR(50),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_addresses_user_id ON user_addresses(user_id);
+
+-- at most one default address per user
+CREATE UNIQUE INDEX idx_user_addresses_one_default
+    ON user_addresses(user_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    level SMALLINT NOT NULL CHECK (level IN (1, 2)),
+    legal_name VARCHAR(255) NOT NULL,
+    date_of_birth DATE,
+    business_name VARCHAR(255),
+    registration_number VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING','APPROVED','REJECTED')),
+    reviewed_by BIGINT REFERENCES users(id),
+    reviewed_at TIMESTAMP,
+    review_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_kyc_submissions_user_id ON kyc_submissions(user_id);
+CREATE INDEX idx_kyc_submissions_status ON kyc_submissions(status);
+
+-- one open submission per user
+CREATE UNIQUE INDEX idx_kyc_submissions_one_pending
+    ON kyc_submissions(user_id) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id BIGINT NOT NULL REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+    doc_type VARCHAR(30) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    blob_key TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_kyc_documents_submission_id ON kyc_documents(submission_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('kyc:review', 'Review identity and business verification submissions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'kyc:review')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,52 @@
+-- Buyer and seller rate each other once per settled auction. reviewee_role
+-- is the part the reviewed user played in the sale, so a user's seller and
+-- buyer ratings are kept apart.
+CREATE TABLE IF NOT EXISTS reviews (
+    id BIGSERIAL PRIMARY KEY,
+    auction_id BIGINT NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+    reviewer_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_role VARCHAR(10) NOT NULL CHECK (reviewee_role IN ('SELLER','BUYER')),
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'VISIBLE' CHECK (status IN ('VISIBLE','HIDDEN')),
+    moderated_by BIGINT REFERENCES users(id),
+    moderated_at TIMESTAMP,
+    moderation_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (auction_id, reviewer_id)
+);
+
+CREATE INDEX idx_reviews_reviewee ON reviews(reviewee_id, reviewee_role) WHERE status = 'VISIBLE';
+CREATE INDEX idx_reviews_status ON reviews(status);
+
+-- A reviewee may contest a review; an admin answers it and, when the
+-- dispute is upheld, hides the review.
+CREATE TABLE IF NOT EXISTS review_disputes (
+    id BIGSERIAL PRIMARY KEY,
+    review_id BIGINT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    opened_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN','UPHELD','DISMISSED')),
+    resolved_by BIGINT REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    response TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_disputes_review_id ON review_disputes(review_id);
+CREATE INDEX idx_review_disputes_status ON review_disputes(status);
+
+-- one open dispute per review
+CREATE UNIQUE INDEX idx_review_disputes_one_open
+    ON review_disputes(review_id) WHERE status = 'OPEN';
+
+INSERT INTO permissions (name, description) VALUES
+    ('review:moderate', 'Hide reviews and answer review disputes')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'review:moderate')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- Whether visitors without an account may watch the auction's live feed.
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS allow_spectators BOOLEAN NOT NULL DEFAULT TRUE;
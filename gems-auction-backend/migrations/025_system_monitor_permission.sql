@@ -0,0 +1,9 @@
+-- Access to operational statistics such as the websocket hub's.
+
+INSERT INTO permissions (name, description) VALUES
+    ('system:monitor', 'View operational statistics')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'system:monitor')
+ON CONFLICT DO NOTHING;
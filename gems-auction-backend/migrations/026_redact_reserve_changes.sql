@@ -0,0 +1,3 @@
+-- The auction change history is public; reserve edits were logged with
+-- the secret values.
+UPDATE auction_changes SET old_value = '', new_value = '' WHERE field = 'reserve_price';
@@ -0,0 +1,6 @@
+-- A rejected registration must not keep the genuine stone from registering
+-- the same lab report later, so only live registrations are unique.
+ALTER TABLE gem_certificates DROP CONSTRAINT IF EXISTS gem_certificates_lab_report_number_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gem_certificates_report
+    ON gem_certificates(lab, report_number) WHERE status <> 'REJECTED';
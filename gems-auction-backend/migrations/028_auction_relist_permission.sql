@@ -0,0 +1,13 @@
+-- Buyers held auction:create so they could relist gems they won, which let
+-- any buyer list and run auctions. They get a narrower permission instead;
+-- AuctionService only lets it list gems the user won at auction.
+INSERT INTO permissions (name, description) VALUES
+    ('auction:relist', 'Relist gems won at auction')
+ON CONFLICT (name) DO NOTHING;
+
+DELETE FROM role_permissions WHERE role = 'BUYER' AND permission = 'auction:create';
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'auction:relist'),
+    ('BUYER', 'auction:relist')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+-- Settlement now opens a PENDING payment for the winner, which the seller
+-- confirms once paid. Auctions sold before that get one too.
+INSERT INTO payments (auction_id, user_id, amount, status, created_at, updated_at)
+SELECT a.id, a.winner_id, a.current_price, 'PENDING', a.updated_at, a.updated_at
+FROM auctions a
+WHERE a.status = 'ENDED' AND a.winner_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.auction_id = a.id AND p.user_id = a.winner_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('payment:confirm', 'Confirm auction payments on the seller''s behalf')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('ADMIN', 'payment:confirm')
+ON CONFLICT DO NOTHING;